@@ -0,0 +1,104 @@
+package webview2
+
+import (
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"reflect"
+
+	"github.com/logicossoftware/go-webview2/internal/bindtype"
+)
+
+// Bytes is binary data exchanged with JavaScript as a Uint8Array.
+//
+// encoding/json marshals a []byte as a base64 string, and a Uint8Array
+// passed from JavaScript cannot be unmarshaled into one. Parameters and
+// results of bound functions that are byte slices are converted
+// automatically; use Bytes for binary fields of structs and other nested
+// values. JavaScript may pass any ArrayBuffer or typed array, and receives a
+// Uint8Array.
+type Bytes []byte
+
+// bytesKey is the property of the object that carries binary data through
+// JSON. The JavaScript runtime encodes and decodes the same envelope.
+const bytesKey = "$bytes"
+
+// MarshalJSON encodes b as a {"$bytes": "<base64>"} envelope.
+func (b Bytes) MarshalJSON() ([]byte, error) {
+	if b == nil {
+		return []byte("null"), nil
+	}
+	buf := make([]byte, 0, base64.StdEncoding.EncodedLen(len(b))+len(bytesKey)+7)
+	buf = append(buf, `{"`+bytesKey+`":"`...)
+	buf = base64.StdEncoding.AppendEncode(buf, b)
+	buf = append(buf, `"}`...)
+	return buf, nil
+}
+
+// UnmarshalJSON decodes the envelope written by MarshalJSON. For
+// compatibility it also accepts a base64 string, as written for a []byte by
+// encoding/json, and an array of numbers.
+func (b *Bytes) UnmarshalJSON(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case nil:
+		*b = nil
+		return nil
+	case string:
+		return b.decode(v)
+	case map[string]interface{}:
+		if s, ok := v[bytesKey].(string); ok && len(v) == 1 {
+			return b.decode(s)
+		}
+	case []interface{}:
+		d := make([]byte, len(v))
+		for i, n := range v {
+			f, ok := n.(float64)
+			if !ok || f < 0 || f > 255 || f != float64(byte(f)) {
+				return errors.New("binary data array contains a value that is not a byte")
+			}
+			d[i] = byte(f)
+		}
+		*b = d
+		return nil
+	}
+	return errors.New("binary data must be a Uint8Array, a base64 string or an array of bytes")
+}
+
+func (b *Bytes) decode(s string) error {
+	d, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	*b = d
+	return nil
+}
+
+var (
+	bytesType         = reflect.TypeOf(Bytes(nil))
+	marshalerType     = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// unmarshalParam decodes a parameter encoded with c. With JSON, it is like
+// json.Unmarshal, but decodes binary parameters like Bytes; binary codecs
+// carry them natively.
+func unmarshalParam(c Codec, data []byte, v reflect.Value) error {
+	if c.Name() != "json" {
+		return c.Unmarshal(data, v.Interface())
+	}
+	t := v.Elem().Type()
+	if !bindtype.IsBinary(t) || t == bytesType {
+		return json.Unmarshal(data, v.Interface())
+	}
+	var b Bytes
+	if err := b.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	v.Elem().Set(reflect.ValueOf([]byte(b)).Convert(t))
+	return nil
+}
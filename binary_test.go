@@ -0,0 +1,143 @@
+package webview2
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+type upload struct {
+	Name string `json:"name"`
+	Data Bytes  `json:"data"`
+}
+
+func TestBytes(t *testing.T) {
+	for _, codec := range []Codec{JSONCodec, MessagePackCodec, CBORCodec} {
+		t.Run(codec.Name(), func(t *testing.T) {
+			f := NewFake(WebViewOptions{Codec: codec})
+			if err := f.Bind("concat", func(b []byte, u upload) []byte { return append(b, u.Data...) }); err != nil {
+				t.Fatal(err)
+			}
+			if err := f.Bind("wrap", func(b []byte) upload { return upload{Name: "x", Data: b} }); err != nil {
+				t.Fatal(err)
+			}
+			res, err := f.Call(t.Context(), "concat", Bytes{1, 2}, upload{Data: Bytes{3}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := `"AQID"`; string(res) != want {
+				t.Errorf("concat = %s, want %s", res, want)
+			}
+			res, err = f.Call(t.Context(), "wrap", Bytes{255})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var u upload
+			if err := json.Unmarshal(res, &u); err != nil || !bytes.Equal(u.Data, []byte{255}) {
+				t.Errorf("wrap = %s, %v", res, err)
+			}
+		})
+	}
+}
+
+func TestBytesUnmarshal(t *testing.T) {
+	for _, tt := range []struct {
+		json string
+		want []byte
+	}{
+		{`{"$bytes":"AQI="}`, []byte{1, 2}},
+		{`"AQI="`, []byte{1, 2}},
+		{`[1,2]`, []byte{1, 2}},
+		{`null`, nil},
+	} {
+		var b Bytes
+		if err := json.Unmarshal([]byte(tt.json), &b); err != nil || !bytes.Equal(b, tt.want) {
+			t.Errorf("Unmarshal(%s) = %v, %v, want %v", tt.json, b, err, tt.want)
+		}
+	}
+	for _, s := range []string{`[256]`, `[1.5]`, `{"$bytes":1}`, `{"a":"AQI="}`, `true`} {
+		var b Bytes
+		if err := json.Unmarshal([]byte(s), &b); err == nil {
+			t.Errorf("Unmarshal(%s) = %v, want an error", s, b)
+		}
+	}
+}
+
+// blobTokens returns the tokens of the blobs waiting to be fetched from f.
+func blobTokens(f *Fake) []string {
+	f.w.m.Lock()
+	defer f.w.m.Unlock()
+	var tokens []string
+	for token := range f.w.blobs {
+		tokens = append(tokens, token)
+	}
+	return tokens
+}
+
+func TestBlob(t *testing.T) {
+	f := NewFake(WebViewOptions{BlobThreshold: 4})
+	if err := f.Bind("read", func(n int) []byte { return bytes.Repeat([]byte{7}, n) }); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Call(t.Context(), "read", 4); err != nil {
+		t.Fatal(err)
+	}
+	if tokens := blobTokens(f); len(tokens) != 0 {
+		t.Errorf("result at the threshold stored as %d blobs", len(tokens))
+	}
+	if _, err := f.Call(t.Context(), "read", 5); err != nil {
+		t.Fatal(err)
+	}
+	tokens := blobTokens(f)
+	if len(tokens) != 1 {
+		t.Fatalf("result above the threshold stored as %d blobs", len(tokens))
+	}
+	token := tokens[0]
+	if b, ok := f.w.takeBlob(token); !ok || !bytes.Equal(b, []byte{7, 7, 7, 7, 7}) {
+		t.Errorf("blob %q = %v, %v", token, b, ok)
+	}
+	if _, ok := f.w.takeBlob(token); ok {
+		t.Error("blob was fetched twice")
+	}
+
+	// Blobs of the previous document are dropped.
+	if _, err := f.Call(t.Context(), "read", 5); err != nil {
+		t.Fatal(err)
+	}
+	f.Navigate("https://app.example/")
+	if tokens := blobTokens(f); len(tokens) != 0 {
+		t.Error("blob survived navigation")
+	}
+}
+
+// TestBlobAborted checks that the result of an aborted call is not stored
+// as a blob that is never fetched.
+func TestBlobAborted(t *testing.T) {
+	f := NewFake(WebViewOptions{BlobThreshold: 4, BindingExecution: BindingExecutionGoroutine})
+	started := make(chan struct{})
+	release := make(chan struct{})
+	if err := f.Bind("read", func() []byte {
+		close(started)
+		<-release
+		return make([]byte, 5)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(t.Context())
+	go func() {
+		<-started
+		cancel()
+	}()
+	if _, err := f.Call(ctx, "read"); err != context.Canceled {
+		t.Fatalf("aborted call = %v", err)
+	}
+	close(release)
+	for len(f.Trace()) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if tokens := blobTokens(f); len(tokens) != 0 {
+		t.Errorf("%d blobs stored for an aborted call", len(tokens))
+	}
+}
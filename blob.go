@@ -0,0 +1,88 @@
+package webview2
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"github.com/logicossoftware/go-webview2/internal/bindtype"
+)
+
+// blobURL is where JavaScript fetches binary results that are too large to
+// be embedded in a script. rpc.js uses the same URL.
+const blobURL = "https://go-webview2.localhost/blob/"
+
+// defaultBlobThreshold is the default of WebViewOptions.BlobThreshold.
+const defaultBlobThreshold = 64 << 10
+
+// blobTTL is how long a blob waits to be fetched. Blobs JavaScript does
+// not fetch, e.g. because the promise they resolve was already rejected,
+// are dropped then.
+const blobTTL = 30 * time.Second
+
+// blob is a binary value waiting to be fetched from blobURL.
+type blob struct {
+	data []byte
+
+	// call is the call the blob is a result or stream item of, if any.
+	// Aborting the call drops the blob.
+	call *pendingCall
+}
+
+// marshalResult encodes a result or stream item of call, or another value
+// for JavaScript if call is nil. Binary values become Uint8Arrays. Those
+// larger than the blob threshold are replaced by a reference JavaScript
+// resolves by fetching them from blobURL, which transfers them without
+// base64 encoding.
+func (w *webview) marshalResult(v interface{}, call *pendingCall) ([]byte, error) {
+	if v == nil || !bindtype.IsBinary(reflect.TypeOf(v)) {
+		return json.Marshal(v)
+	}
+	data := reflect.ValueOf(v).Bytes()
+	if w.blobThreshold < 0 || len(data) <= w.blobThreshold {
+		return Bytes(data).MarshalJSON()
+	}
+	var key [16]byte
+	_, _ = rand.Read(key[:])
+	token := hex.EncodeToString(key[:])
+	b := &blob{data: data, call: call}
+	w.m.Lock()
+	if call != nil && call.aborted.Load() {
+		// cancelCall already dropped the blobs of call.
+		w.m.Unlock()
+		return json.Marshal(map[string]string{"$blob": token})
+	}
+	w.blobs[token] = b
+	w.m.Unlock()
+	time.AfterFunc(blobTTL, func() {
+		w.m.Lock()
+		if w.blobs[token] == b {
+			delete(w.blobs, token)
+		}
+		w.m.Unlock()
+	})
+	return json.Marshal(map[string]string{"$blob": token})
+}
+
+// takeBlob removes the blob of token and returns its data.
+func (w *webview) takeBlob(token string) ([]byte, bool) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	b, ok := w.blobs[token]
+	if !ok {
+		return nil, false
+	}
+	delete(w.blobs, token)
+	return b.data, true
+}
+
+// dropBlobs drops the blobs of call. The caller must hold w.m.
+func (w *webview) dropBlobs(call *pendingCall) {
+	for token, b := range w.blobs {
+		if b.call == call {
+			delete(w.blobs, token)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package webview2
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// cborFormat implements CBOR as defined by RFC 8949. Tags are skipped when
+// decoding, and indefinite-length items are not supported.
+type cborFormat struct{}
+
+// CBOR major types.
+const (
+	cborUint   = 0 << 5
+	cborNegInt = 1 << 5
+	cborBytes  = 2 << 5
+	cborText   = 3 << 5
+	cborArray  = 4 << 5
+	cborMap    = 5 << 5
+	cborTag    = 6 << 5
+	cborSimple = 7 << 5
+)
+
+func cborHeader(b []byte, major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return append(b, major|byte(n))
+	case n <= math.MaxUint8:
+		return append(b, major|24, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, major|25), uint16(n))
+	case n <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(b, major|26), uint32(n))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, major|27), n)
+	}
+}
+
+func (cborFormat) appendNil(b []byte) []byte { return append(b, cborSimple|22) }
+
+func (cborFormat) appendBool(b []byte, v bool) []byte {
+	if v {
+		return append(b, cborSimple|21)
+	}
+	return append(b, cborSimple|20)
+}
+
+func (cborFormat) appendInt(b []byte, v int64) []byte {
+	if v < 0 {
+		return cborHeader(b, cborNegInt, uint64(-1-v))
+	}
+	return cborHeader(b, cborUint, uint64(v))
+}
+
+func (cborFormat) appendUint(b []byte, v uint64) []byte { return cborHeader(b, cborUint, v) }
+
+func (cborFormat) appendFloat(b []byte, v float64, bits int) []byte {
+	if bits == 32 {
+		return binary.BigEndian.AppendUint32(append(b, cborSimple|26), math.Float32bits(float32(v)))
+	}
+	return binary.BigEndian.AppendUint64(append(b, cborSimple|27), math.Float64bits(v))
+}
+
+func (cborFormat) appendString(b []byte, v string) []byte {
+	return append(cborHeader(b, cborText, uint64(len(v))), v...)
+}
+
+func (cborFormat) appendBytes(b []byte, v []byte) []byte {
+	return append(cborHeader(b, cborBytes, uint64(len(v))), v...)
+}
+
+func (cborFormat) appendArrayHeader(b []byte, n int) []byte {
+	return cborHeader(b, cborArray, uint64(n))
+}
+
+func (cborFormat) appendMapHeader(b []byte, n int) []byte {
+	return cborHeader(b, cborMap, uint64(n))
+}
+
+func (f cborFormat) read(b []byte) (interface{}, []byte, error) {
+	if len(b) == 0 {
+		return nil, nil, errTruncated
+	}
+	major, info, b := b[0]&0xe0, b[0]&0x1f, b[1:]
+
+	var arg uint64
+	switch {
+	case info < 24:
+		arg = uint64(info)
+	case info <= 27:
+		n := 1 << (info - 24)
+		if len(b) < n {
+			return nil, nil, errTruncated
+		}
+		for _, c := range b[:n] {
+			arg = arg<<8 | uint64(c)
+		}
+		b = b[n:]
+	default:
+		return nil, nil, errors.New("cbor: indefinite-length items are not supported")
+	}
+
+	switch major {
+	case cborUint:
+		if arg <= math.MaxInt64 {
+			return int64(arg), b, nil
+		}
+		return arg, b, nil
+	case cborNegInt:
+		if arg > math.MaxInt64 {
+			return -1 - float64(arg), b, nil
+		}
+		return -1 - int64(arg), b, nil
+	case cborBytes, cborText:
+		if arg > uint64(len(b)) {
+			return nil, nil, errTruncated
+		}
+		s, rest, err := readString(b, int(arg))
+		if err != nil || major == cborText {
+			return s, rest, err
+		}
+		return []byte(s.(string)), rest, nil
+	case cborArray:
+		if arg > uint64(len(b)) {
+			return nil, nil, errTruncated
+		}
+		return readArray(f, b, int(arg))
+	case cborMap:
+		if arg > uint64(len(b)) {
+			return nil, nil, errTruncated
+		}
+		return readMap(f, b, int(arg))
+	case cborTag:
+		return f.read(b)
+	}
+
+	switch info {
+	case 20:
+		return false, b, nil
+	case 21:
+		return true, b, nil
+	case 22, 23:
+		return nil, b, nil
+	case 25:
+		return float16(uint16(arg)), b, nil
+	case 26:
+		return float64(math.Float32frombits(uint32(arg))), b, nil
+	case 27:
+		return math.Float64frombits(arg), b, nil
+	}
+	return nil, nil, fmt.Errorf("cbor: unsupported simple value %d", arg)
+}
+
+// float16 converts an IEEE 754 half-precision number.
+func float16(h uint16) float64 {
+	exp := int(h>>10) & 0x1f
+	mant := float64(h & 0x3ff)
+	var v float64
+	switch exp {
+	case 0:
+		v = math.Ldexp(mant, -24)
+	case 0x1f:
+		if mant == 0 {
+			v = math.Inf(1)
+		} else {
+			v = math.NaN()
+		}
+	default:
+		v = math.Ldexp(mant+1024, exp-25)
+	}
+	if h&0x8000 != 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,115 @@
+package webview2
+
+import (
+	"encoding/json"
+	"hash/crc32"
+	"log"
+	"strconv"
+	"unicode/utf8"
+)
+
+// Defaults of WebViewOptions.ChunkThreshold and ChunkSize. Chunks are
+// smaller than the threshold, so that a chunked message has several of
+// them and the runtime sees a transfer progress.
+const (
+	defaultChunkThreshold = 256 << 10
+	defaultChunkSize      = 64 << 10
+)
+
+// message is a call of a function of window._rpc, to be delivered to a
+// document by deliver.
+type message struct {
+	// js evaluates the call. It is empty if the call is too large to be
+	// evaluated efficiently, and payload is posted in chunks instead.
+	js string
+
+	// payload is [op, args...] encoded as JSON, or base64-encoded with a
+	// binary codec.
+	payload string
+
+	// call is the call the message settles or feeds, if any, so that
+	// JavaScript can reject it if the chunks of the message are corrupt.
+	call int
+}
+
+// chunk is posted with PostWebMessageAsJSON, wrapped in an object with the
+// single member $rpcChunk.
+type chunk struct {
+	// Seq is the number of the delivery the chunk belongs to.
+	Seq   uint64 `json:"seq"`
+	Index int    `json:"index"`
+	Count int    `json:"count"`
+	Call  int    `json:"call,omitempty"`
+
+	// CRC is the CRC-32 (IEEE) of the UTF-8 encoding of Data.
+	CRC  uint32 `json:"crc"`
+	Data string `json:"data"`
+}
+
+// chunked reports whether a message of size bytes is posted in chunks.
+func (w *webview) chunked(size int) bool {
+	return w.chunkThreshold >= 0 && size > w.chunkThreshold
+}
+
+// deliver delivers m to the current document. It must be called on the UI
+// thread.
+//
+// Chunks are posted as web messages, which may overtake scripts evaluated
+// before them or be overtaken by those evaluated after. Unless chunking is
+// disabled, deliveries are therefore numbered, and window._rpc.ordered
+// runs them in order. Chunks that arrive late are waited for. If posting a
+// chunk fails, the delivery is replaced by a call of window._rpc.abandon,
+// which rejects the call the message belongs to, so that it does not hold
+// up all later deliveries.
+func (w *webview) deliver(m message) {
+	if w.chunkThreshold < 0 {
+		w.browser.Eval(m.js)
+		return
+	}
+	w.m.Lock()
+	w.deliveries++
+	seq := w.deliveries
+	w.m.Unlock()
+	ordered := "window._rpc.ordered(" + strconv.FormatUint(seq, 10) + ", function() {"
+	if m.payload == "" {
+		w.browser.Eval(ordered + m.js + "})")
+		return
+	}
+	parts := splitChunks(m.payload, w.chunkSize)
+	for i, data := range parts {
+		b, err := json.Marshal(map[string]chunk{"$rpcChunk": {
+			Seq:   seq,
+			Index: i,
+			Count: len(parts),
+			Call:  m.call,
+			CRC:   crc32.ChecksumIEEE([]byte(data)),
+			Data:  data,
+		}})
+		if err == nil {
+			err = w.browser.PostMessageJSON(string(b))
+		}
+		if err != nil {
+			log.Printf("failed to post chunk: %v", err)
+			w.browser.Eval(ordered + "window._rpc.abandon(" + strconv.FormatUint(seq, 10) + ", " + strconv.Itoa(m.call) + ")})")
+			return
+		}
+	}
+}
+
+// splitChunks splits s into parts of at most size bytes, without splitting
+// UTF-8 sequences.
+func splitChunks(s string, size int) []string {
+	var parts []string
+	for len(s) > size {
+		n := size
+		for n > 0 && !utf8.RuneStart(s[n]) {
+			n--
+		}
+		if n == 0 {
+			n = size
+		}
+		parts = append(parts, s[:n])
+		s = s[n:]
+	}
+	return append(parts, s)
+}
@@ -0,0 +1,205 @@
+package webview2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChunkSize(t *testing.T) {
+	for _, tt := range []struct {
+		threshold, size, want int
+	}{
+		{0, 0, defaultChunkSize},
+		{16 << 10, 0, 16 << 10},
+		{-1, 0, defaultChunkSize},
+		{16 << 10, 1 << 20, 1 << 20},
+	} {
+		f := NewFake(WebViewOptions{ChunkThreshold: tt.threshold, ChunkSize: tt.size})
+		if f.w.chunkSize != tt.want {
+			t.Errorf("ChunkThreshold %d, ChunkSize %d: chunk size %d, want %d", tt.threshold, tt.size, f.w.chunkSize, tt.want)
+		}
+		if f.w.chunkSize > f.w.chunkThreshold && f.w.chunkThreshold > 0 && tt.size == 0 {
+			t.Errorf("ChunkThreshold %d: default chunk size %d is larger", tt.threshold, f.w.chunkSize)
+		}
+	}
+}
+
+func TestSplitChunks(t *testing.T) {
+	for _, tt := range []struct {
+		s    string
+		size int
+		want []string
+	}{
+		{"", 4, []string{""}},
+		{"abcd", 4, []string{"abcd"}},
+		{"abcdefghij", 4, []string{"abcd", "efgh", "ij"}},
+		{"aéb", 2, []string{"a", "é", "b"}},
+		{"ab😀c", 4, []string{"ab", "😀", "c"}},
+		{"😀", 2, []string{"\xf0\x9f", "\x98\x80"}},
+	} {
+		got := splitChunks(tt.s, tt.size)
+		if !equalStrings(got, tt.want) {
+			t.Errorf("splitChunks(%q, %d) = %q, want %q", tt.s, tt.size, got, tt.want)
+		}
+	}
+}
+
+// chunkBrowser counts the chunks posted to the document and corrupts the
+// data of those for which corrupt returns true. Posting those for which
+// fail returns true fails, and those for which hold returns true are sent
+// to held instead, for the test to deliver later.
+type chunkBrowser struct {
+	browser
+	posted  *int
+	corrupt func(c chunk) bool
+	fail    func(c chunk) bool
+	hold    func(c chunk) bool
+	held    chan string
+}
+
+func (b chunkBrowser) PostMessageJSON(data string) error {
+	*b.posted++
+	var m map[string]chunk
+	if err := json.Unmarshal([]byte(data), &m); err != nil {
+		panic(err)
+	}
+	c := m["$rpcChunk"]
+	switch {
+	case b.corrupt != nil && b.corrupt(c):
+		c.Data = "?" + c.Data[1:]
+		b2, _ := json.Marshal(map[string]chunk{"$rpcChunk": c})
+		data = string(b2)
+	case b.fail != nil && b.fail(c):
+		return errors.New("posting failed")
+	case b.hold != nil && b.hold(c):
+		b.held <- data
+		return nil
+	}
+	return b.browser.PostMessageJSON(data)
+}
+
+func TestChunkedCall(t *testing.T) {
+	large := strings.Repeat("é0123456789", 1000)
+	for _, codec := range []Codec{JSONCodec, MessagePackCodec, CBORCodec} {
+		t.Run(codec.Name(), func(t *testing.T) {
+			f := NewFake(WebViewOptions{Codec: codec, ChunkThreshold: 4 << 10, ChunkSize: 1 << 10})
+			var posted int
+			f.w.browser = chunkBrowser{browser: f.w.browser, posted: &posted}
+			if err := f.Bind("large", func() string { return large }); err != nil {
+				t.Fatal(err)
+			}
+			if err := f.Bind("items", func() <-chan string {
+				c := make(chan string, 2)
+				c <- "small"
+				c <- large
+				close(c)
+				return c
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			res, err := f.Call(context.Background(), "large")
+			var s string
+			if err != nil || json.Unmarshal(res, &s) != nil || s != large {
+				t.Fatalf("large = %.40s, %v", res, err)
+			}
+			if posted < 10 {
+				t.Errorf("result posted in %d chunks", posted)
+			}
+
+			res, err = f.Call(context.Background(), "items")
+			var items []string
+			if err != nil || json.Unmarshal(res, &items) != nil || len(items) != 2 || items[1] != large {
+				t.Errorf("items = %.40s, %v", res, err)
+			}
+		})
+	}
+}
+
+func TestCorruptChunk(t *testing.T) {
+	f := NewFake(WebViewOptions{ChunkThreshold: 4 << 10, ChunkSize: 1 << 10})
+	var posted int
+	f.w.browser = chunkBrowser{browser: f.w.browser, posted: &posted, corrupt: func(c chunk) bool { return c.Index == 2 }}
+	if err := f.Bind("large", func() string { return strings.Repeat("x", 10000) }); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Call(context.Background(), "large"); err != errCorruptMessage {
+		t.Errorf("call with a corrupt chunk = %v, want %v", err, errCorruptMessage)
+	}
+}
+
+func TestUndeliverableChunk(t *testing.T) {
+	f := NewFake(WebViewOptions{ChunkThreshold: 4 << 10, ChunkSize: 1 << 10})
+	var posted int
+	fail := true
+	f.w.browser = chunkBrowser{browser: f.w.browser, posted: &posted, fail: func(c chunk) bool { return fail && c.Index == 2 }}
+	if err := f.Bind("large", func() string { return strings.Repeat("x", 10000) }); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Call(context.Background(), "large"); err != errUndeliverable {
+		t.Errorf("call whose chunks failed to post = %v, want %v", err, errUndeliverable)
+	}
+	// Later deliveries are not held up.
+	fail = false
+	if _, err := f.Call(context.Background(), "large"); err != nil {
+		t.Errorf("next call = %v", err)
+	}
+}
+
+// TestDelayedChunk checks that a message whose chunks arrive late is
+// waited for, and that the messages delivered after it wait for it.
+func TestDelayedChunk(t *testing.T) {
+	large := strings.Repeat("x", 10000)
+	f := NewFake(WebViewOptions{ChunkThreshold: 4 << 10, ChunkSize: 1 << 10, BindingExecution: BindingExecutionGoroutine})
+	var posted int
+	held := make(chan string, 1)
+	f.w.browser = chunkBrowser{browser: f.w.browser, posted: &posted, hold: func(c chunk) bool { return c.Index == 2 }, held: held}
+	if err := f.Bind("large", func() string { return large }); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Bind("small", func() int { return 1 }); err != nil {
+		t.Fatal(err)
+	}
+
+	largeDone := make(chan error, 1)
+	go func() {
+		res, err := f.Call(context.Background(), "large")
+		var s string
+		if err == nil && (json.Unmarshal(res, &s) != nil || s != large) {
+			err = errors.New("wrong result")
+		}
+		largeDone <- err
+	}()
+	chunk := <-held
+	smallDone := make(chan error, 1)
+	go func() {
+		_, err := f.Call(context.Background(), "small")
+		smallDone <- err
+	}()
+
+	// The small result is delivered after the large one, so it waits
+	// however late the held chunk is.
+	for len(f.Trace()) < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	select {
+	case err := <-smallDone:
+		t.Fatalf("small call returned before the large one: %v", err)
+	default:
+	}
+
+	if err := f.w.browser.(chunkBrowser).browser.PostMessageJSON(chunk); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-largeDone; err != nil {
+		t.Errorf("large = %v", err)
+	}
+	if err := <-smallDone; err != nil {
+		t.Errorf("small = %v", err)
+	}
+}
@@ -877,6 +877,16 @@ const vaultHTML = `<!doctype html>
 </body>
 </html>`
 
+// vaultSchema checks the arguments of the pw_* bindings.
+const vaultSchema = `{
+	"maxItems": 3,
+	"prefixItems": [
+		{"type": "string", "minLength": 1, "maxLength": 255},
+		{"type": "string", "minLength": 1, "maxLength": 64},
+		{"type": "string", "minLength": 1, "maxLength": 256}
+	]
+}`
+
 func main() {
 	st := newStore()
 	pk := newPasskeyStore()
@@ -904,26 +914,33 @@ func main() {
 	}
 	defer w.Destroy()
 
-	// Password vault ("password manager") APIs.
-	_ = w.Bind("pw_save", func(siteKey, username, password string) error {
+	// Password vault ("password manager") APIs. Only the demo site may use
+	// them, not the sites it links to. They take the site key first, and
+	// pw_save the username and password next.
+	opts := webview2.BindOptions{
+		AllowedOrigins: []string{ds.baseURL},
+		ParamsSchema:   json.RawMessage(vaultSchema),
+	}
+	_ = w.BindWithOptions("pw_save", func(siteKey, username, password string) error {
 		log.Printf("pw_save: site=%q user=%q (password length=%d)", siteKey, username, len(password))
 		st.save(siteKey, username, password)
 		return nil
-	})
-	_ = w.Bind("pw_get", func(siteKey string) (*credential, error) {
+	}, opts)
+	_ = w.BindWithOptions("pw_get", func(info webview2.CallInfo, siteKey string) (*credential, error) {
+		log.Printf("pw_get: site=%q from %s (navigation %d, call %d)", siteKey, info.Source, info.NavigationID, info.CallID)
 		if c, ok := st.get(siteKey); ok {
 			return &c, nil
 		}
 		return nil, nil
-	})
-	_ = w.Bind("pw_list", func(siteKey string) ([]storedCredential, error) {
+	}, opts)
+	_ = w.BindWithOptions("pw_list", func(siteKey string) ([]storedCredential, error) {
 		return st.list(siteKey), nil
-	})
-	_ = w.Bind("pw_clear", func() error {
+	}, opts)
+	_ = w.BindWithOptions("pw_clear", func() error {
 		log.Printf("pw_clear")
 		st.clear()
 		return nil
-	})
+	}, opts)
 
 	log.Printf("demo site running at %s", ds.baseURL)
 
@@ -0,0 +1,192 @@
+// Command webview2-tsgen writes TypeScript declarations for the functions a
+// Go package binds with go-webview2.
+//
+// It type-checks the package in the given directory (the current directory
+// by default) for Windows, finds every call to WebView.Bind, BindObject and
+// their WithOptions variants whose name is a constant string, and writes a .d.ts
+// file declaring them on Window:
+//
+//	webview2-tsgen -o frontend/src/bindings.d.ts ./cmd/app
+//
+// Functions bound with a name computed at run time are skipped with a
+// warning. Use webview2.WriteTypeScript to generate declarations from a
+// running webview instead.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/constant"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/logicossoftware/go-webview2/pkg/tsgen"
+)
+
+const webviewPath = "github.com/logicossoftware/go-webview2"
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("webview2-tsgen: ")
+
+	out := flag.String("o", "", "write declarations to `file` instead of stdout")
+	namespace := flag.String("namespace", tsgen.DefaultNamespace, "TypeScript `namespace` for generated interfaces")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: webview2-tsgen [flags] [dir]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	dir := "."
+	switch flag.NArg() {
+	case 0:
+	case 1:
+		dir = flag.Arg(0)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	g := &tsgen.Generator{Namespace: *namespace}
+	if err := load(g, dir); err != nil {
+		log.Fatal(err)
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := g.Write(w); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// load type-checks the package in dir and adds its bindings to g.
+func load(g *tsgen.Generator, dir string) error {
+	// Applications of go-webview2 are Windows programs and may constrain
+	// their files to Windows, so they are type-checked as on Windows. The
+	// source importer uses build.Default, so it has to be changed rather
+	// than copied.
+	build.Default.GOOS = "windows"
+	bp, err := build.Default.ImportDir(dir, 0)
+	if err != nil {
+		return err
+	}
+
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, name := range bp.GoFiles {
+		f, err := parser.ParseFile(fset, filepath.Join(bp.Dir, name), nil, 0)
+		if err != nil {
+			return err
+		}
+		files = append(files, f)
+	}
+
+	info := &types.Info{
+		Types: map[ast.Expr]types.TypeAndValue{},
+		Uses:  map[*ast.Ident]types.Object{},
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	if _, err := conf.Check(bp.ImportPath, fset, files, info); err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		var err error
+		ast.Inspect(f, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || err != nil {
+				return err == nil
+			}
+			switch calledMethod(info, call) {
+			case "Bind", "BindWithOptions":
+				err = addBinding(g, fset, info, call)
+			case "BindObject", "BindObjectWithOptions":
+				err = addObject(g, fset, info, call)
+			}
+			return true
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// calledMethod returns the name of the go-webview2 method called by call, or
+// "" if call is not such a method call.
+func calledMethod(info *types.Info, call *ast.CallExpr) string {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return ""
+	}
+	fn, ok := info.Uses[sel.Sel].(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != webviewPath {
+		return ""
+	}
+	if sig, ok := fn.Type().(*types.Signature); !ok || sig.Recv() == nil {
+		return ""
+	}
+	return fn.Name()
+}
+
+func addBinding(g *tsgen.Generator, fset *token.FileSet, info *types.Info, call *ast.CallExpr) error {
+	name, ok := constantName(fset, info, call)
+	if !ok {
+		return nil
+	}
+	sig, ok := info.TypeOf(call.Args[1]).Underlying().(*types.Signature)
+	if !ok {
+		log.Printf("%s: skipping binding of non-function", fset.Position(call.Pos()))
+		return nil
+	}
+	return g.AddSignature(name, sig)
+}
+
+// addObject adds the exported methods of the value passed to BindObject.
+func addObject(g *tsgen.Generator, fset *token.FileSet, info *types.Info, call *ast.CallExpr) error {
+	namespace, ok := constantName(fset, info, call)
+	if !ok {
+		return nil
+	}
+	mset := types.NewMethodSet(info.TypeOf(call.Args[1]))
+	for i := 0; i < mset.Len(); i++ {
+		m := mset.At(i)
+		if !m.Obj().Exported() {
+			continue
+		}
+		name := namespace + "." + tsgen.MethodName(m.Obj().Name())
+		if err := g.AddSignature(name, m.Type().(*types.Signature)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// constantName returns the first argument of call, which must be a constant
+// string.
+func constantName(fset *token.FileSet, info *types.Info, call *ast.CallExpr) (string, bool) {
+	if len(call.Args) < 2 {
+		return "", false
+	}
+	name := info.Types[call.Args[0]].Value
+	if name == nil || name.Kind() != constant.String {
+		log.Printf("%s: skipping binding with non-constant name", fset.Position(call.Pos()))
+		return "", false
+	}
+	return constant.StringVal(name), true
+}
@@ -0,0 +1,699 @@
+package webview2
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/logicossoftware/go-webview2/internal/bindtype"
+)
+
+// Codec encodes the messages exchanged between Go and JavaScript.
+//
+// JSONCodec is the default. MessagePackCodec and CBORCodec encode messages
+// more compactly and carry binary data without base64 encoding it. They
+// follow the rules of encoding/json for struct fields and json tags, and
+// convert values implementing json.Marshaler or json.Unmarshaler through
+// their JSON encoding.
+type Codec interface {
+	// Name selects the matching encoder of the JavaScript runtime, which
+	// implements "json", "msgpack" and "cbor".
+	Name() string
+
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+var (
+	// JSONCodec encodes messages with encoding/json.
+	JSONCodec Codec = jsonCodec{}
+
+	// MessagePackCodec encodes messages as MessagePack.
+	MessagePackCodec Codec = binaryCodec{name: "msgpack", format: msgpackFormat{}}
+
+	// CBORCodec encodes messages as CBOR (RFC 8949).
+	CBORCodec Codec = binaryCodec{name: "cbor", format: cborFormat{}}
+)
+
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string                               { return "json" }
+func (jsonCodec) Marshal(v interface{}) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+
+// format writes and reads the data items of a binary encoding. Reading
+// produces generic values: nil, bool, int64, uint64, float64, string,
+// []byte, []interface{} and map[string]interface{}.
+type format interface {
+	appendNil(b []byte) []byte
+	appendBool(b []byte, v bool) []byte
+	appendInt(b []byte, v int64) []byte
+	appendUint(b []byte, v uint64) []byte
+	appendFloat(b []byte, v float64, bits int) []byte
+	appendString(b []byte, v string) []byte
+	appendBytes(b []byte, v []byte) []byte
+	appendArrayHeader(b []byte, n int) []byte
+	appendMapHeader(b []byte, n int) []byte
+
+	// read decodes the data item at the start of b and returns the rest.
+	read(b []byte) (interface{}, []byte, error)
+}
+
+type binaryCodec struct {
+	name   string
+	format format
+}
+
+func (c binaryCodec) Name() string { return c.name }
+
+func (c binaryCodec) Marshal(v interface{}) ([]byte, error) {
+	return c.encode(nil, reflect.ValueOf(v))
+}
+
+func (c binaryCodec) Unmarshal(data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return errors.New(c.name + ": Unmarshal of non-pointer")
+	}
+	g, rest, err := c.format.read(data)
+	if err != nil {
+		return err
+	}
+	if len(rest) > 0 {
+		return errors.New(c.name + ": trailing data")
+	}
+	return c.assign(g, rv.Elem())
+}
+
+var errTruncated = errors.New("unexpected end of data")
+
+var (
+	rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+	stringType     = reflect.TypeOf("")
+)
+
+func (c binaryCodec) encode(b []byte, v reflect.Value) ([]byte, error) {
+	f := c.format
+	if !v.IsValid() {
+		return f.appendNil(b), nil
+	}
+	t := v.Type()
+	switch {
+	case bindtype.IsBinary(t):
+		if v.IsNil() {
+			return f.appendNil(b), nil
+		}
+		return f.appendBytes(b, v.Bytes()), nil
+	case t.Implements(marshalerType) && !(t.Kind() == reflect.Pointer && v.IsNil()):
+		j, err := v.Interface().(json.Marshaler).MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+		return c.encodeJSON(b, j)
+	case t.Kind() != reflect.Pointer && reflect.PointerTo(t).Implements(marshalerType):
+		p := reflect.New(t)
+		p.Elem().Set(v)
+		return c.encode(b, p)
+	case t.Implements(textMarshalerType) && !(t.Kind() == reflect.Pointer && v.IsNil()):
+		s, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return nil, err
+		}
+		return f.appendString(b, string(s)), nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return f.appendBool(b, v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return f.appendInt(b, v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return f.appendUint(b, v.Uint()), nil
+	case reflect.Float32:
+		return f.appendFloat(b, v.Float(), 32), nil
+	case reflect.Float64:
+		return f.appendFloat(b, v.Float(), 64), nil
+	case reflect.String:
+		return f.appendString(b, v.String()), nil
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return f.appendNil(b), nil
+		}
+		return c.encode(b, v.Elem())
+	case reflect.Slice:
+		if v.IsNil() {
+			return f.appendNil(b), nil
+		}
+		fallthrough
+	case reflect.Array:
+		b = f.appendArrayHeader(b, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			var err error
+			if b, err = c.encode(b, v.Index(i)); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	case reflect.Map:
+		if v.IsNil() {
+			return f.appendNil(b), nil
+		}
+		keys := make([]string, 0, v.Len())
+		values := make(map[string]reflect.Value, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			k, err := mapKey(iter.Key())
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, k)
+			values[k] = iter.Value()
+		}
+		sort.Strings(keys)
+		b = f.appendMapHeader(b, len(keys))
+		for _, k := range keys {
+			var err error
+			b = f.appendString(b, k)
+			if b, err = c.encode(b, values[k]); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	case reflect.Struct:
+		fields := cachedFields(t)
+		present := make([]reflect.Value, 0, len(fields))
+		for _, fi := range fields {
+			fv, ok := fieldByIndex(v, fi.index)
+			if !ok || fi.omitEmpty && isEmptyValue(fv) {
+				present = append(present, reflect.Value{})
+				continue
+			}
+			present = append(present, fv)
+		}
+		n := 0
+		for _, fv := range present {
+			if fv.IsValid() {
+				n++
+			}
+		}
+		b = f.appendMapHeader(b, n)
+		for i, fv := range present {
+			if !fv.IsValid() {
+				continue
+			}
+			var err error
+			b = f.appendString(b, fields[i].name)
+			if b, err = c.encode(b, fv); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	default:
+		return nil, errors.New(c.name + ": unsupported type " + t.String())
+	}
+}
+
+// encodeJSON encodes the value of the JSON document j.
+func (c binaryCodec) encodeJSON(b []byte, j []byte) ([]byte, error) {
+	d := json.NewDecoder(bytes.NewReader(j))
+	d.UseNumber()
+	var g interface{}
+	if err := d.Decode(&g); err != nil {
+		return nil, err
+	}
+	return c.encode(b, reflect.ValueOf(fromJSONNumbers(g)))
+}
+
+// fromJSONNumbers replaces the json.Numbers in g by integers where
+// possible and floats otherwise.
+func fromJSONNumbers(g interface{}) interface{} {
+	switch g := g.(type) {
+	case json.Number:
+		if i, err := g.Int64(); err == nil {
+			return i
+		}
+		f, _ := g.Float64()
+		return f
+	case []interface{}:
+		for i := range g {
+			g[i] = fromJSONNumbers(g[i])
+		}
+	case map[string]interface{}:
+		for k, v := range g {
+			g[k] = fromJSONNumbers(v)
+		}
+	}
+	return g
+}
+
+func mapKey(k reflect.Value) (string, error) {
+	if k.Kind() == reflect.String {
+		return k.String(), nil
+	}
+	if tm, ok := k.Interface().(encoding.TextMarshaler); ok {
+		if k.Kind() == reflect.Pointer && k.IsNil() {
+			return "", nil
+		}
+		s, err := tm.MarshalText()
+		return string(s), err
+	}
+	switch k.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(k.Uint(), 10), nil
+	}
+	return "", errors.New("unsupported map key type " + k.Type().String())
+}
+
+// assign stores the generic value g in v.
+func (c binaryCodec) assign(g interface{}, v reflect.Value) error {
+	t := v.Type()
+	if t == rawMessageType {
+		b, err := c.encode(nil, reflect.ValueOf(g))
+		if err != nil {
+			return err
+		}
+		v.SetBytes(b)
+		return nil
+	}
+	if g == nil {
+		switch t.Kind() {
+		case reflect.Interface, reflect.Pointer, reflect.Map, reflect.Slice:
+			v.Set(reflect.Zero(t))
+		}
+		return nil
+	}
+	if t.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(t.Elem()))
+		}
+		return c.assign(g, v.Elem())
+	}
+	if bindtype.IsBinary(t) {
+		switch g := g.(type) {
+		case []byte:
+			v.SetBytes(append([]byte(nil), g...))
+			return nil
+		case string:
+			d, err := base64.StdEncoding.DecodeString(g)
+			if err != nil {
+				return err
+			}
+			v.SetBytes(d)
+			return nil
+		}
+	}
+	if v.CanAddr() {
+		switch u := v.Addr().Interface().(type) {
+		case json.Unmarshaler:
+			j, err := json.Marshal(g)
+			if err != nil {
+				return err
+			}
+			return u.UnmarshalJSON(j)
+		case encoding.TextUnmarshaler:
+			if s, ok := g.(string); ok {
+				return u.UnmarshalText([]byte(s))
+			}
+		}
+	}
+
+	mismatch := func() error {
+		return fmt.Errorf("%s: cannot decode %T into %s", c.name, g, t)
+	}
+	switch t.Kind() {
+	case reflect.Interface:
+		if t.NumMethod() > 0 {
+			return mismatch()
+		}
+		v.Set(reflect.ValueOf(toJSONValue(g)))
+	case reflect.Bool:
+		b, ok := g.(bool)
+		if !ok {
+			return mismatch()
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i int64
+		switch n := g.(type) {
+		case int64:
+			i = n
+		case uint64:
+			if n > math.MaxInt64 {
+				return mismatch()
+			}
+			i = int64(n)
+		case float64:
+			if n != math.Trunc(n) || n < math.MinInt64 || n >= math.MaxInt64 {
+				return mismatch()
+			}
+			i = int64(n)
+		default:
+			return mismatch()
+		}
+		if v.OverflowInt(i) {
+			return mismatch()
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		var u uint64
+		switch n := g.(type) {
+		case int64:
+			if n < 0 {
+				return mismatch()
+			}
+			u = uint64(n)
+		case uint64:
+			u = n
+		case float64:
+			if n != math.Trunc(n) || n < 0 || n >= math.MaxUint64 {
+				return mismatch()
+			}
+			u = uint64(n)
+		default:
+			return mismatch()
+		}
+		if v.OverflowUint(u) {
+			return mismatch()
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		switch n := g.(type) {
+		case int64:
+			v.SetFloat(float64(n))
+		case uint64:
+			v.SetFloat(float64(n))
+		case float64:
+			v.SetFloat(n)
+		default:
+			return mismatch()
+		}
+	case reflect.String:
+		s, ok := g.(string)
+		if !ok {
+			return mismatch()
+		}
+		v.SetString(s)
+	case reflect.Slice:
+		items, ok := g.([]interface{})
+		if !ok {
+			return mismatch()
+		}
+		s := reflect.MakeSlice(t, len(items), len(items))
+		for i, item := range items {
+			if err := c.assign(item, s.Index(i)); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+	case reflect.Array:
+		items, ok := g.([]interface{})
+		if !ok {
+			return mismatch()
+		}
+		for i := 0; i < v.Len(); i++ {
+			var item interface{}
+			if i < len(items) {
+				item = items[i]
+			}
+			if err := c.assign(item, v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		m, ok := g.(map[string]interface{})
+		if !ok {
+			return mismatch()
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMapWithSize(t, len(m)))
+		}
+		for k, item := range m {
+			key := reflect.New(t.Key()).Elem()
+			if err := setMapKey(key, k); err != nil {
+				return err
+			}
+			elem := reflect.New(t.Elem()).Elem()
+			if err := c.assign(item, elem); err != nil {
+				return err
+			}
+			v.SetMapIndex(key, elem)
+		}
+	case reflect.Struct:
+		m, ok := g.(map[string]interface{})
+		if !ok {
+			return mismatch()
+		}
+		fields := cachedFields(t)
+		for k, item := range m {
+			fi := findField(fields, k)
+			if fi == nil {
+				continue
+			}
+			fv, err := fieldForSet(v, fi.index)
+			if err != nil {
+				return err
+			}
+			if err := c.assign(item, fv); err != nil {
+				return err
+			}
+		}
+	default:
+		return mismatch()
+	}
+	return nil
+}
+
+// toJSONValue converts a generic value to what encoding/json would have
+// stored in an interface{}, so bound functions see the same values whatever
+// the codec.
+func toJSONValue(g interface{}) interface{} {
+	switch g := g.(type) {
+	case int64:
+		return float64(g)
+	case uint64:
+		return float64(g)
+	case []byte:
+		return base64.StdEncoding.EncodeToString(g)
+	case []interface{}:
+		for i := range g {
+			g[i] = toJSONValue(g[i])
+		}
+	case map[string]interface{}:
+		for k, v := range g {
+			g[k] = toJSONValue(v)
+		}
+	}
+	return g
+}
+
+func setMapKey(key reflect.Value, k string) error {
+	if tu, ok := key.Addr().Interface().(encoding.TextUnmarshaler); ok && key.Kind() != reflect.String {
+		return tu.UnmarshalText([]byte(k))
+	}
+	switch key.Kind() {
+	case reflect.String:
+		key.Set(reflect.ValueOf(k).Convert(key.Type()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(k, 10, 64)
+		if err != nil || key.OverflowInt(i) {
+			return errors.New("invalid map key " + strconv.Quote(k))
+		}
+		key.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u, err := strconv.ParseUint(k, 10, 64)
+		if err != nil || key.OverflowUint(u) {
+			return errors.New("invalid map key " + strconv.Quote(k))
+		}
+		key.SetUint(u)
+	default:
+		return errors.New("unsupported map key type " + key.Type().String())
+	}
+	return nil
+}
+
+// structField is a field of a struct as seen by encoding/json.
+type structField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+var fieldCache sync.Map // map[reflect.Type][]structField
+
+func cachedFields(t reflect.Type) []structField {
+	if f, ok := fieldCache.Load(t); ok {
+		return f.([]structField)
+	}
+	f, _ := fieldCache.LoadOrStore(t, typeFields(t))
+	return f.([]structField)
+}
+
+// typeFields returns the fields of struct type t, including those promoted
+// from embedded structs. Like encoding/json, shallower fields hide deeper
+// ones of the same name, and fields of the same name at the same depth are
+// dropped unless exactly one of them is named by a tag.
+func typeFields(t reflect.Type) []structField {
+	var fields []structField
+	seen := map[string]bool{}
+	type level struct {
+		t     reflect.Type
+		index []int
+	}
+	type candidate struct {
+		structField
+		tagged bool
+	}
+	current := []level{{t: t}}
+	visited := map[reflect.Type]bool{}
+	for len(current) > 0 {
+		var next []level
+		var found []candidate
+		for _, l := range current {
+			// A struct embedded twice at the same depth is explored twice,
+			// so that its fields conflict.
+			if visited[l.t] {
+				continue
+			}
+			for i := 0; i < l.t.NumField(); i++ {
+				sf := l.t.Field(i)
+				tag := sf.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+				name, opts, _ := strings.Cut(tag, ",")
+				index := append(l.index[:len(l.index):len(l.index)], i)
+				ft := sf.Type
+				if ft.Kind() == reflect.Pointer {
+					ft = ft.Elem()
+				}
+				if sf.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+					next = append(next, level{t: ft, index: index})
+					continue
+				}
+				if !sf.IsExported() {
+					continue
+				}
+				tagged := name != ""
+				if !tagged {
+					name = sf.Name
+				}
+				found = append(found, candidate{structField{
+					name:      name,
+					index:     index,
+					omitEmpty: strings.Contains(","+opts+",", ",omitempty,"),
+				}, tagged})
+			}
+		}
+		for _, l := range current {
+			visited[l.t] = true
+		}
+		for i, f := range found {
+			if seen[f.name] {
+				continue
+			}
+			seen[f.name] = true
+			dominant, ok := &found[i], true
+			for j := i + 1; j < len(found); j++ {
+				switch g := &found[j]; {
+				case g.name != f.name:
+				case g.tagged && !dominant.tagged:
+					dominant, ok = g, true
+				case g.tagged == dominant.tagged:
+					ok = false
+				}
+			}
+			if ok {
+				fields = append(fields, dominant.structField)
+			}
+		}
+		current = next
+	}
+	// Fields are encoded in the order of their declaration.
+	sort.Slice(fields, func(i, j int) bool {
+		a, b := fields[i].index, fields[j].index
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+	return fields
+}
+
+// findField returns the field named name, matching case-insensitively if
+// there is no exact match like encoding/json does.
+func findField(fields []structField, name string) *structField {
+	for i := range fields {
+		if fields[i].name == name {
+			return &fields[i]
+		}
+	}
+	for i := range fields {
+		if strings.EqualFold(fields[i].name, name) {
+			return &fields[i]
+		}
+	}
+	return nil
+}
+
+// fieldByIndex is like reflect.Value.FieldByIndex, but reports false
+// instead of panicking on nil embedded pointers.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// fieldForSet returns the field at index, allocating nil embedded pointers.
+func fieldForSet(v reflect.Value, index []int) (reflect.Value, error) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, errors.New("cannot set embedded pointer to unexported struct")
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, nil
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Pointer:
+		return v.IsNil()
+	}
+	return false
+}
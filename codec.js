@@ -0,0 +1,518 @@
+(function() {
+	if (window._rpcCodecs) {
+		return;
+	}
+
+	var utf8Encoder = new TextEncoder();
+	var utf8Decoder = new TextDecoder();
+
+	// Writer collects the bytes of an encoded value.
+	function Writer() {
+		this.buf = new Uint8Array(256);
+		this.view = new DataView(this.buf.buffer);
+		this.pos = 0;
+	}
+
+	Writer.prototype.reserve = function(n) {
+		if (this.pos + n <= this.buf.length) {
+			return;
+		}
+		var size = this.buf.length * 2;
+		while (size < this.pos + n) {
+			size *= 2;
+		}
+		var buf = new Uint8Array(size);
+		buf.set(this.buf.subarray(0, this.pos));
+		this.buf = buf;
+		this.view = new DataView(buf.buffer);
+	};
+
+	Writer.prototype.u8 = function(v) {
+		this.reserve(1);
+		this.buf[this.pos++] = v;
+	};
+
+	Writer.prototype.u16 = function(v) {
+		this.reserve(2);
+		this.view.setUint16(this.pos, v);
+		this.pos += 2;
+	};
+
+	Writer.prototype.u32 = function(v) {
+		this.reserve(4);
+		this.view.setUint32(this.pos, v);
+		this.pos += 4;
+	};
+
+	Writer.prototype.u64 = function(v) {
+		this.reserve(8);
+		this.view.setBigUint64(this.pos, BigInt.asUintN(64, BigInt(v)));
+		this.pos += 8;
+	};
+
+	Writer.prototype.f64 = function(v) {
+		this.reserve(8);
+		this.view.setFloat64(this.pos, v);
+		this.pos += 8;
+	};
+
+	Writer.prototype.bytes = function(b) {
+		this.reserve(b.length);
+		this.buf.set(b, this.pos);
+		this.pos += b.length;
+	};
+
+	// Reader reads the bytes of an encoded value.
+	function Reader(bytes) {
+		this.buf = bytes;
+		this.view = new DataView(bytes.buffer, bytes.byteOffset, bytes.byteLength);
+		this.pos = 0;
+	}
+
+	Reader.prototype.need = function(n) {
+		if (this.pos + n > this.buf.length) {
+			throw new Error("unexpected end of data");
+		}
+		var pos = this.pos;
+		this.pos += n;
+		return pos;
+	};
+
+	Reader.prototype.u8 = function() {
+		return this.buf[this.need(1)];
+	};
+
+	Reader.prototype.u16 = function() {
+		return this.view.getUint16(this.need(2));
+	};
+
+	Reader.prototype.u32 = function() {
+		return this.view.getUint32(this.need(4));
+	};
+
+	Reader.prototype.u64 = function() {
+		return Number(this.view.getBigUint64(this.need(8)));
+	};
+
+	Reader.prototype.i64 = function() {
+		return Number(this.view.getBigInt64(this.need(8)));
+	};
+
+	Reader.prototype.bytes = function(n) {
+		var pos = this.need(n);
+		return this.buf.slice(pos, pos + n);
+	};
+
+	Reader.prototype.str = function(n) {
+		var pos = this.need(n);
+		return utf8Decoder.decode(this.buf.subarray(pos, pos + n));
+	};
+
+	Reader.prototype.array = function(format, n) {
+		var items = new Array(n);
+		for (var i = 0; i < n; i++) {
+			items[i] = format.read(this);
+		}
+		return items;
+	};
+
+	Reader.prototype.map = function(format, n) {
+		var obj = {};
+		for (var i = 0; i < n; i++) {
+			var key = format.read(this);
+			obj[String(key)] = format.read(this);
+		}
+		return obj;
+	};
+
+	// encode encodes value in format. replace is called with every value
+	// before it is encoded, like the replacer of JSON.stringify. Values are
+	// otherwise encoded like JSON.stringify does, except that binary data
+	// is kept.
+	function encode(format, value, replace) {
+		var w = new Writer();
+
+		function prepare(value) {
+			if (replace) {
+				value = replace(value);
+			}
+			if (value !== null && typeof value === "object" && typeof value.toJSON === "function") {
+				value = value.toJSON();
+			}
+			return value;
+		}
+
+		function skipped(value) {
+			return value === undefined || typeof value === "function" || typeof value === "symbol";
+		}
+
+		function write(value) {
+			if (value === null || skipped(value)) {
+				format.nil(w);
+				return;
+			}
+			switch (typeof value) {
+			case "boolean":
+				format.bool(w, value);
+				return;
+			case "number":
+				if (Number.isSafeInteger(value)) {
+					format.int(w, value);
+				} else {
+					format.float(w, value);
+				}
+				return;
+			case "bigint":
+				format.int(w, value);
+				return;
+			case "string":
+				format.str(w, utf8Encoder.encode(value));
+				return;
+			}
+			if (value instanceof ArrayBuffer) {
+				format.bin(w, new Uint8Array(value));
+				return;
+			}
+			if (ArrayBuffer.isView(value)) {
+				format.bin(w, new Uint8Array(value.buffer, value.byteOffset, value.byteLength));
+				return;
+			}
+			if (Array.isArray(value)) {
+				format.array(w, value.length);
+				for (var i = 0; i < value.length; i++) {
+					write(prepare(value[i]));
+				}
+				return;
+			}
+			var entries = [];
+			Object.keys(value).forEach(function(key) {
+				var v = prepare(value[key]);
+				if (!skipped(v)) {
+					entries.push([key, v]);
+				}
+			});
+			format.map(w, entries.length);
+			entries.forEach(function(e) {
+				format.str(w, utf8Encoder.encode(e[0]));
+				write(e[1]);
+			});
+		}
+
+		write(prepare(value));
+		return w.buf.slice(0, w.pos);
+	}
+
+	function decode(format, bytes) {
+		var r = new Reader(bytes);
+		var value = format.read(r);
+		if (r.pos !== bytes.length) {
+			throw new Error("trailing data");
+		}
+		return value;
+	}
+
+	// msgpack implements MessagePack like msgpack.go.
+	var msgpack = {
+		nil: function(w) {
+			w.u8(0xc0);
+		},
+		bool: function(w, v) {
+			w.u8(v ? 0xc3 : 0xc2);
+		},
+		int: function(w, v) {
+			if (v >= 0) {
+				if (v < 0x80) {
+					w.u8(Number(v));
+				} else if (v <= 0xff) {
+					w.u8(0xcc);
+					w.u8(Number(v));
+				} else if (v <= 0xffff) {
+					w.u8(0xcd);
+					w.u16(Number(v));
+				} else if (v <= 0xffffffff) {
+					w.u8(0xce);
+					w.u32(Number(v));
+				} else {
+					w.u8(0xcf);
+					w.u64(v);
+				}
+			} else if (v >= -32) {
+				w.u8(Number(v) & 0xff);
+			} else if (v >= -0x80) {
+				w.u8(0xd0);
+				w.u8(Number(v) & 0xff);
+			} else if (v >= -0x8000) {
+				w.u8(0xd1);
+				w.u16(Number(v) & 0xffff);
+			} else if (v >= -0x80000000) {
+				w.u8(0xd2);
+				w.u32(Number(v) >>> 0);
+			} else {
+				w.u8(0xd3);
+				w.u64(v);
+			}
+		},
+		float: function(w, v) {
+			w.u8(0xcb);
+			w.f64(v);
+		},
+		str: function(w, b) {
+			var n = b.length;
+			if (n < 32) {
+				w.u8(0xa0 | n);
+			} else if (n <= 0xff) {
+				w.u8(0xd9);
+				w.u8(n);
+			} else if (n <= 0xffff) {
+				w.u8(0xda);
+				w.u16(n);
+			} else {
+				w.u8(0xdb);
+				w.u32(n);
+			}
+			w.bytes(b);
+		},
+		bin: function(w, b) {
+			var n = b.length;
+			if (n <= 0xff) {
+				w.u8(0xc4);
+				w.u8(n);
+			} else if (n <= 0xffff) {
+				w.u8(0xc5);
+				w.u16(n);
+			} else {
+				w.u8(0xc6);
+				w.u32(n);
+			}
+			w.bytes(b);
+		},
+		array: function(w, n) {
+			if (n < 16) {
+				w.u8(0x90 | n);
+			} else if (n <= 0xffff) {
+				w.u8(0xdc);
+				w.u16(n);
+			} else {
+				w.u8(0xdd);
+				w.u32(n);
+			}
+		},
+		map: function(w, n) {
+			if (n < 16) {
+				w.u8(0x80 | n);
+			} else if (n <= 0xffff) {
+				w.u8(0xde);
+				w.u16(n);
+			} else {
+				w.u8(0xdf);
+				w.u32(n);
+			}
+		},
+		read: function(r) {
+			var t = r.u8();
+			if (t < 0x80) {
+				return t;
+			}
+			if (t >= 0xe0) {
+				return t - 0x100;
+			}
+			if ((t & 0xf0) === 0x80) {
+				return r.map(msgpack, t & 0x0f);
+			}
+			if ((t & 0xf0) === 0x90) {
+				return r.array(msgpack, t & 0x0f);
+			}
+			if ((t & 0xe0) === 0xa0) {
+				return r.str(t & 0x1f);
+			}
+			switch (t) {
+			case 0xc0:
+				return null;
+			case 0xc2:
+				return false;
+			case 0xc3:
+				return true;
+			case 0xc4:
+				return r.bytes(r.u8());
+			case 0xc5:
+				return r.bytes(r.u16());
+			case 0xc6:
+				return r.bytes(r.u32());
+			case 0xca:
+				return r.view.getFloat32(r.need(4));
+			case 0xcb:
+				return r.view.getFloat64(r.need(8));
+			case 0xcc:
+				return r.u8();
+			case 0xcd:
+				return r.u16();
+			case 0xce:
+				return r.u32();
+			case 0xcf:
+				return r.u64();
+			case 0xd0:
+				return r.view.getInt8(r.need(1));
+			case 0xd1:
+				return r.view.getInt16(r.need(2));
+			case 0xd2:
+				return r.view.getInt32(r.need(4));
+			case 0xd3:
+				return r.i64();
+			case 0xd9:
+				return r.str(r.u8());
+			case 0xda:
+				return r.str(r.u16());
+			case 0xdb:
+				return r.str(r.u32());
+			case 0xdc:
+				return r.array(msgpack, r.u16());
+			case 0xdd:
+				return r.array(msgpack, r.u32());
+			case 0xde:
+				return r.map(msgpack, r.u16());
+			case 0xdf:
+				return r.map(msgpack, r.u32());
+			}
+			throw new Error("msgpack: unsupported type 0x" + t.toString(16));
+		},
+	};
+
+	// cborHead writes the initial byte and argument of a CBOR data item.
+	function cborHead(w, major, n) {
+		if (n < 24) {
+			w.u8(major | Number(n));
+		} else if (n <= 0xff) {
+			w.u8(major | 24);
+			w.u8(Number(n));
+		} else if (n <= 0xffff) {
+			w.u8(major | 25);
+			w.u16(Number(n));
+		} else if (n <= 0xffffffff) {
+			w.u8(major | 26);
+			w.u32(Number(n));
+		} else {
+			w.u8(major | 27);
+			w.u64(n);
+		}
+	}
+
+	function float16(h) {
+		var exp = (h >> 10) & 0x1f;
+		var mant = h & 0x3ff;
+		var v;
+		if (exp === 0) {
+			v = mant * Math.pow(2, -24);
+		} else if (exp === 0x1f) {
+			v = mant ? NaN : Infinity;
+		} else {
+			v = (mant + 1024) * Math.pow(2, exp - 25);
+		}
+		return h & 0x8000 ? -v : v;
+	}
+
+	// cbor implements CBOR like cbor.go.
+	var cbor = {
+		nil: function(w) {
+			w.u8(0xf6);
+		},
+		bool: function(w, v) {
+			w.u8(v ? 0xf5 : 0xf4);
+		},
+		int: function(w, v) {
+			if (v >= 0) {
+				cborHead(w, 0x00, v);
+			} else if (typeof v === "bigint") {
+				cborHead(w, 0x20, -1n - v);
+			} else {
+				cborHead(w, 0x20, -1 - v);
+			}
+		},
+		float: function(w, v) {
+			w.u8(0xfb);
+			w.f64(v);
+		},
+		str: function(w, b) {
+			cborHead(w, 0x60, b.length);
+			w.bytes(b);
+		},
+		bin: function(w, b) {
+			cborHead(w, 0x40, b.length);
+			w.bytes(b);
+		},
+		array: function(w, n) {
+			cborHead(w, 0x80, n);
+		},
+		map: function(w, n) {
+			cborHead(w, 0xa0, n);
+		},
+		read: function(r) {
+			var ib = r.u8();
+			var major = ib >> 5;
+			var info = ib & 0x1f;
+			if (major === 7) {
+				switch (info) {
+				case 20:
+					return false;
+				case 21:
+					return true;
+				case 22:
+				case 23:
+					return null;
+				case 25:
+					return float16(r.u16());
+				case 26:
+					return r.view.getFloat32(r.need(4));
+				case 27:
+					return r.view.getFloat64(r.need(8));
+				}
+				throw new Error("cbor: unsupported simple value " + info);
+			}
+			var arg;
+			if (info < 24) {
+				arg = info;
+			} else if (info === 24) {
+				arg = r.u8();
+			} else if (info === 25) {
+				arg = r.u16();
+			} else if (info === 26) {
+				arg = r.u32();
+			} else if (info === 27) {
+				arg = r.u64();
+			} else {
+				throw new Error("cbor: indefinite-length items are not supported");
+			}
+			switch (major) {
+			case 0:
+				return arg;
+			case 1:
+				return -1 - arg;
+			case 2:
+				return r.bytes(arg);
+			case 3:
+				return r.str(arg);
+			case 4:
+				return r.array(cbor, arg);
+			case 5:
+				return r.map(cbor, arg);
+			}
+			// Tags are skipped.
+			return cbor.read(r);
+		},
+	};
+
+	function codec(format) {
+		return {
+			encode: function(value, replace) {
+				return encode(format, value, replace);
+			},
+			decode: function(bytes) {
+				return decode(format, bytes);
+			},
+		};
+	}
+
+	window._rpcCodecs = {
+		msgpack: codec(msgpack),
+		cbor: codec(cbor),
+	};
+})();
@@ -0,0 +1,156 @@
+package webview2
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type codecValue struct {
+	Nil     interface{}       `json:"nil"`
+	Bool    bool              `json:"bool"`
+	Ints    []int64           `json:"ints"`
+	Uints   []uint64          `json:"uints"`
+	Floats  []float64         `json:"floats"`
+	Float32 float32           `json:"float32"`
+	Strings []string          `json:"strings"`
+	Bytes   Bytes             `json:"bytes"`
+	Map     map[string]int    `json:"map"`
+	Nested  *codecValue       `json:"nested,omitempty"`
+	Time    time.Time         `json:"time"`
+	Any     []interface{}     `json:"any"`
+	Skipped string            `json:"-"`
+	Tagged  map[string]string `json:"tagged,omitempty"`
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	in := codecValue{
+		Bool:    true,
+		Ints:    []int64{0, 1, -1, 127, 128, -32, -33, 255, 256, -129, 65535, 65536, -32769, math.MaxInt32 + 1, math.MinInt64, math.MaxInt64},
+		Uints:   []uint64{0, math.MaxUint32, math.MaxUint32 + 1, math.MaxUint64},
+		Floats:  []float64{0, 1.5, -2.25, math.MaxFloat64, math.SmallestNonzeroFloat64},
+		Float32: 3.5,
+		Strings: []string{"", "a", strings.Repeat("x", 31), strings.Repeat("y", 32), strings.Repeat("z", 256), strings.Repeat("w", 70000), "é€😀"},
+		Bytes:   Bytes{0, 1, 2, 255},
+		Map:     map[string]int{"a": 1, "b": -2},
+		Nested:  &codecValue{Strings: []string{"inner"}},
+		Time:    time.Date(2024, 2, 29, 12, 30, 0, 0, time.UTC),
+		Any:     []interface{}{"s", true, nil},
+	}
+	for _, codec := range []Codec{JSONCodec, MessagePackCodec, CBORCodec} {
+		t.Run(codec.Name(), func(t *testing.T) {
+			b, err := codec.Marshal(in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var out codecValue
+			if err := codec.Unmarshal(b, &out); err != nil {
+				t.Fatal(err)
+			}
+			vin, vout := reflect.ValueOf(in), reflect.ValueOf(out)
+			for i := 0; i < vin.NumField(); i++ {
+				if name := vin.Type().Field(i).Name; name != "Skipped" && !reflect.DeepEqual(vout.Field(i).Interface(), vin.Field(i).Interface()) {
+					t.Errorf("%s = %.100v, want %.100v", name, vout.Field(i).Interface(), vin.Field(i).Interface())
+				}
+			}
+		})
+	}
+}
+
+func TestCodecGeneric(t *testing.T) {
+	for _, codec := range []Codec{MessagePackCodec, CBORCodec} {
+		b, err := codec.Marshal([]interface{}{"resolve", 7, map[string]interface{}{"a": []int{1}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var msg []interface{}
+		if err := codec.Unmarshal(b, &msg); err != nil {
+			t.Fatal(err)
+		}
+		if len(msg) != 3 || msg[0] != "resolve" {
+			t.Fatalf("%s: decoded %v", codec.Name(), msg)
+		}
+		if id, ok := toInt(msg[1]); !ok || id != 7 {
+			t.Errorf("%s: id %v", codec.Name(), msg[1])
+		}
+		if m, ok := msg[2].(map[string]interface{}); !ok || len(m["a"].([]interface{})) != 1 {
+			t.Errorf("%s: decoded %#v", codec.Name(), msg[2])
+		}
+	}
+}
+
+func TestCodecErrors(t *testing.T) {
+	for _, codec := range []Codec{MessagePackCodec, CBORCodec} {
+		b, err := codec.Marshal(map[string]string{"a": "b"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var v map[string]string
+		if err := codec.Unmarshal(b[:len(b)-1], &v); err == nil {
+			t.Errorf("%s: truncated data decoded as %v", codec.Name(), v)
+		}
+		if _, err := codec.Marshal(make(chan int)); err == nil {
+			t.Errorf("%s: encoded a channel", codec.Name())
+		}
+	}
+}
+
+type embeddedA struct {
+	Name string
+	ID   int `json:"id"`
+	Both int
+}
+
+type embeddedB struct {
+	Name string
+	ID   int
+	Both int
+}
+
+type embeddedC struct {
+	Both int `json:"Both"`
+}
+
+type conflicting struct {
+	embeddedA
+	embeddedB
+	Outer struct {
+		embeddedC
+	}
+	embeddedC
+	Own int
+}
+
+// TestCodecFieldConflicts checks that the binary codecs encode the fields of
+// embedded structs like encoding/json does.
+func TestCodecFieldConflicts(t *testing.T) {
+	in := conflicting{embeddedA: embeddedA{Name: "a", ID: 1, Both: 2}, embeddedB: embeddedB{Name: "b", ID: 3, Both: 4}, Own: 5}
+	in.embeddedC.Both = 6
+	want, err := toJSONDoc(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, codec := range []Codec{MessagePackCodec, CBORCodec} {
+		b, err := codec.Marshal(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got interface{}
+		if err := codec.Unmarshal(b, &got); err != nil {
+			t.Fatal(err)
+		}
+		if got = toJSONValue(got); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: encoded %v, want %v", codec.Name(), got, want)
+		}
+
+		var out conflicting
+		if err := codec.Unmarshal(b, &out); err != nil {
+			t.Fatal(err)
+		}
+		if out.embeddedA.ID != 1 || out.embeddedA.Name != "" || out.embeddedB.ID != 3 || out.embeddedB.Both != 0 || out.embeddedC.Both != 6 || out.Own != 5 {
+			t.Errorf("%s: decoded %+v", codec.Name(), out)
+		}
+	}
+}
@@ -1,27 +1,14 @@
 package webview2
 
 import (
+	"context"
+	"encoding/json"
 	"unsafe"
-
-	"github.com/logicossoftware/go-webview2/pkg/edge"
 )
 
 // This is copied from webview/webview.
 // The documentation is included for convenience.
 
-// HostResourceAccessKind specifies the kind of cross-origin resource access
-// allowed for a virtual host name mapping.
-type HostResourceAccessKind = edge.COREWEBVIEW2_HOST_RESOURCE_ACCESS_KIND
-
-const (
-	// HostResourceAccessKindDeny denies all cross-origin resource access.
-	HostResourceAccessKindDeny HostResourceAccessKind = edge.COREWEBVIEW2_HOST_RESOURCE_ACCESS_KIND_DENY
-	// HostResourceAccessKindAllow allows all cross-origin resource access.
-	HostResourceAccessKindAllow HostResourceAccessKind = edge.COREWEBVIEW2_HOST_RESOURCE_ACCESS_KIND_ALLOW
-	// HostResourceAccessKindDenyCors denies CORS but allows other cross-origin resource access.
-	HostResourceAccessKindDenyCors HostResourceAccessKind = edge.COREWEBVIEW2_HOST_RESOURCE_ACCESS_KIND_DENY_CORS
-)
-
 // Hint is used to configure window sizing and resizing behavior.
 type Hint int
 
@@ -85,10 +72,21 @@ type WebView interface {
 	Init(js string)
 
 	// Eval evaluates arbitrary JavaScript code. Evaluation happens asynchronously,
-	// also the result of the expression is ignored. Use RPC bindings if you want
-	// to receive notifications about the results of the evaluation.
+	// also the result of the expression is ignored. Use EvalResult or RPC
+	// bindings if you want to receive the results of the evaluation.
 	Eval(js string)
 
+	// EvalResult evaluates js like Eval and returns its result encoded as
+	// JSON. If the result is a promise, EvalResult waits for it to settle. A
+	// JavaScript exception, including a syntax error, is returned as a
+	// *JSError. js is run once with eval in the global scope, so it may be an
+	// expression or a list of statements, whose value is that of the last
+	// one; the Content-Security-Policy of the page must allow 'unsafe-eval'.
+	// Functions in the result are left out, as by JSON.stringify.
+	// EvalResult waits for the UI thread, so it must be called from another
+	// goroutine.
+	EvalResult(ctx context.Context, js string) (json.RawMessage, error)
+
 	// Bind binds a callback function so that it will appear under the given name
 	// as a global JavaScript function. Internally it uses webview_init().
 	// Callback receives a request string and a user-provided argument pointer.
@@ -97,8 +95,110 @@ type WebView interface {
 	//
 	// f must be a function
 	// f must return either value and error or just error
+	//
+	// If f returns a non-nil error, the promise is rejected with a
+	// window.go.GoError. Besides the message, it carries the code, status and
+	// data of errors implementing CodedError, StatusError or DataError (see
+	// also RegisterErrorCode), and the chain of wrapped errors. If f panics,
+	// the panic is recovered and the promise is rejected with a
+	// window.go.GoPanic instead (see WebViewOptions.OnBindingPanic).
+	//
+	// Arguments are checked against the validate tags of their struct fields
+	// (see ValidationError) and BindOptions.ParamsSchema before f is called.
+	//
+	// If the first parameter of f is a context.Context, it is not decoded from
+	// the JavaScript arguments. The context is cancelled when the call
+	// returns, when the page navigates away or the webview is destroyed, and
+	// when JavaScript passes an AbortSignal as the last argument and aborts
+	// it. An aborted call rejects its promise with the signal's reason.
+	//
+	// Parameters of type CallInfo are not decoded either; they describe the
+	// document, navigation and call that invoked f.
+	//
+	// If f returns a receive channel or an iter.Seq, the JavaScript function
+	// returns an async iterator instead of a promise, to be consumed with
+	// for await. Items are sent as they are produced, but Go waits for
+	// JavaScript to catch up when more than a few items are outstanding.
+	// Leaving the loop early cancels the context of f and stops the
+	// iteration; a channel is simply no longer read from.
+	//
+	// Parameters and results of f that are byte slices are exchanged as
+	// binary data: JavaScript passes an ArrayBuffer or typed array and
+	// receives a Uint8Array. Use Bytes for binary data nested in other
+	// values.
+	//
+	// A JavaScript function, e.g. a progress callback, can be passed to a
+	// parameter of type JSFunc, or to one nested in a parameter, and called
+	// from Go until it is released.
+	//
+	// The JavaScript function is installed in the current document and in
+	// new ones, so functions can be bound at any time. Must be called from
+	// the UI thread.
+	//
+	// Unless WebViewOptions.BindingExecution is BindingExecutionSync, f is
+	// called from a goroutine other than the UI thread and must use Dispatch
+	// to interact with the window.
 	Bind(name string, f interface{}) error
 
+	// BindWithOptions is like Bind, but configures the binding with opts,
+	// e.g. to restrict the origins that may call it.
+	BindWithOptions(name string, f interface{}, opts BindOptions) error
+
+	// BindObject binds every exported method of v as a function of a
+	// JavaScript object named by namespace, which may be dotted to create
+	// nested objects. For example, binding a value with a Read method under
+	// "app.files" creates window.app.files.read. Method names are converted
+	// to lower camel case. Methods follow the same rules as functions passed
+	// to Bind; pass a pointer to include methods with pointer receivers.
+	BindObject(namespace string, v interface{}) error
+
+	// BindObjectWithOptions is like BindObject, but configures the bindings
+	// of all methods with opts.
+	BindObjectWithOptions(namespace string, v interface{}, opts BindOptions) error
+
+	// Unbind removes the function bound under name, or every function bound
+	// below the namespace name with BindObject. The JavaScript function is
+	// deleted from the current document and no longer installed in new
+	// ones. Binding a name again replaces the previous function without
+	// calling Unbind. Must be called from the UI thread.
+	Unbind(name string) error
+
+	// Bindings describes the bound functions, sorted by name. JavaScript gets
+	// the same list from window.go.__bindings(). Calls of names that are not
+	// bound are rejected with a GoError with code "not_found".
+	Bindings() []BindingInfo
+
+	// Emit sends an event with payload to the JavaScript listeners of topic
+	// registered with window.go.on. The payload is marshaled like the results
+	// of bound functions. It is safe to call Emit from any goroutine.
+	Emit(topic string, payload interface{}) error
+
+	// On subscribes handler to the events JavaScript emits with
+	// window.go.emit on topics matching pattern. Topics consist of segments
+	// separated by dots; in pattern, "*" matches any single segment and a
+	// final "**" matches any remaining segments. handler takes the payload,
+	// which is unmarshaled into the type of its last parameter, optionally
+	// preceded by the topic as a string, and may return an error to be
+	// logged. Handlers run like bound functions. The returned function
+	// unsubscribes handler.
+	On(pattern string, handler interface{}) (off func(), err error)
+
+	// Once is like On, but unsubscribes handler after the first event.
+	Once(pattern string, handler interface{}) (off func(), err error)
+
+	// Off unsubscribes all handlers registered for pattern.
+	Off(pattern string)
+
+	// Use adds middleware around every call of a bound function, for example
+	// to authorize, log or time calls. The middleware added first is the
+	// outermost.
+	Use(mw ...BindingMiddleware)
+
+	// Metrics returns a snapshot of the counters and latency histograms of
+	// the calls of bound functions. Metrics.WritePrometheus and
+	// MetricsHandler export them in the Prometheus text format.
+	Metrics() Metrics
+
 	// SetVirtualHostNameToFolderMapping sets a mapping between a virtual host name
 	// and a folder path to make available to web content via that host name.
 	// For example, mapping "assets.example" to "C:\app\assets" allows the web page
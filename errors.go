@@ -0,0 +1,160 @@
+package webview2
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// CodedError is implemented by errors that carry a stable code JavaScript can
+// switch on. When a bound function returns an error, the first error in its
+// chain implementing CodedError provides the code property of the GoError
+// the promise is rejected with.
+type CodedError interface {
+	error
+	ErrorCode() string
+}
+
+// StatusError is implemented by errors that carry an HTTP-like status. It
+// provides the status property of the rejected GoError.
+type StatusError interface {
+	error
+	ErrorStatus() int
+}
+
+// DataError is implemented by errors that carry additional data for
+// JavaScript. The data is marshaled to JSON and provides the data property
+// of the rejected GoError.
+type DataError interface {
+	error
+	ErrorData() interface{}
+}
+
+// Error is an error with a code, status and data for JavaScript. It
+// implements CodedError, StatusError and DataError.
+type Error struct {
+	Code    string
+	Message string
+	Status  int
+	Data    interface{}
+
+	// Err is the wrapped error, if any.
+	Err error
+}
+
+func (e *Error) Error() string {
+	switch {
+	case e.Err == nil:
+		return e.Message
+	case e.Message == "":
+		return e.Err.Error()
+	default:
+		return e.Message + ": " + e.Err.Error()
+	}
+}
+
+func (e *Error) Unwrap() error          { return e.Err }
+func (e *Error) ErrorCode() string      { return e.Code }
+func (e *Error) ErrorStatus() int       { return e.Status }
+func (e *Error) ErrorData() interface{} { return e.Data }
+
+type errorCode struct {
+	err  error
+	code string
+}
+
+var (
+	errorCodes     []errorCode
+	errorCodesSync sync.RWMutex
+)
+
+// RegisterErrorCode assigns code to errors that match err according to
+// errors.Is, so sentinel errors can be told apart in JavaScript. Codes
+// provided by a CodedError in the chain take precedence. Sentinels are
+// matched in the order they were registered.
+func RegisterErrorCode(err error, code string) {
+	errorCodesSync.Lock()
+	defer errorCodesSync.Unlock()
+	errorCodes = append(errorCodes, errorCode{err: err, code: code})
+}
+
+func registeredErrorCode(err error) string {
+	errorCodesSync.RLock()
+	defer errorCodesSync.RUnlock()
+	for _, c := range errorCodes {
+		if errors.Is(err, c.err) {
+			return c.code
+		}
+	}
+	return ""
+}
+
+// jsError is the representation of a Go error sent to JavaScript, where it
+// becomes a GoError.
+type jsError struct {
+	Name    string        `json:"name"`
+	Message string        `json:"message"`
+	Code    string        `json:"code,omitempty"`
+	Status  int           `json:"status,omitempty"`
+	Data    interface{}   `json:"data,omitempty"`
+	Chain   []jsErrorLink `json:"chain,omitempty"`
+}
+
+// jsErrorLink describes one error of a chain as walked by errors.Unwrap.
+type jsErrorLink struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+func newJSError(err error) jsError {
+	e := jsError{Name: "GoError", Message: err.Error()}
+	if _, ok := err.(*PanicError); ok {
+		e.Name = "GoPanic"
+	}
+	var coded CodedError
+	if errors.As(err, &coded) {
+		e.Code = coded.ErrorCode()
+	}
+	if e.Code == "" {
+		e.Code = registeredErrorCode(err)
+	}
+	var status StatusError
+	if errors.As(err, &status) {
+		e.Status = status.ErrorStatus()
+	}
+	var data DataError
+	if errors.As(err, &data) {
+		e.Data = data.ErrorData()
+	}
+	e.Chain = errorChain(err, e.Chain)
+	return e
+}
+
+// errorChain appends err and the errors it wraps, depth first.
+func errorChain(err error, chain []jsErrorLink) []jsErrorLink {
+	for err != nil {
+		chain = append(chain, jsErrorLink{Type: fmt.Sprintf("%T", err), Message: err.Error()})
+		if multi, ok := err.(interface{ Unwrap() []error }); ok {
+			for _, e := range multi.Unwrap() {
+				chain = errorChain(e, chain)
+			}
+			return chain
+		}
+		err = errors.Unwrap(err)
+	}
+	return chain
+}
+
+// JSError is an exception thrown by JavaScript code called from Go.
+type JSError struct {
+	Name    string `json:"name"`
+	Message string `json:"message"`
+	Stack   string `json:"stack,omitempty"`
+}
+
+func (e *JSError) Error() string {
+	if e.Name == "" {
+		return e.Message
+	}
+	return e.Name + ": " + e.Message
+}
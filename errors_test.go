@@ -0,0 +1,89 @@
+package webview2
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+var (
+	errTestMissing = errors.New("missing")
+	errTestLocked  = errors.New("locked")
+)
+
+func init() {
+	RegisterErrorCode(errTestMissing, "missing")
+	RegisterErrorCode(errTestLocked, "locked")
+}
+
+// quotaError carries a status and data for JavaScript.
+type quotaError struct{ used, limit int }
+
+func (e quotaError) Error() string          { return "quota exceeded" }
+func (e quotaError) ErrorStatus() int       { return 429 }
+func (e quotaError) ErrorData() interface{} { return map[string]int{"used": e.used, "limit": e.limit} }
+
+func TestNewJSError(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		err  error
+		want jsError
+	}{
+		{"plain", errors.New("boom"), jsError{
+			Name: "GoError", Message: "boom",
+			Chain: []jsErrorLink{{"*errors.errorString", "boom"}},
+		}},
+		{"registered", fmt.Errorf("load: %w", errTestMissing), jsError{
+			Name: "GoError", Message: "load: missing", Code: "missing",
+			Chain: []jsErrorLink{{"*fmt.wrapError", "load: missing"}, {"*errors.errorString", "missing"}},
+		}},
+		{"coded before registered", &Error{Code: "gone", Status: 410, Message: "gone", Err: errTestMissing}, jsError{
+			Name: "GoError", Message: "gone: missing", Code: "gone", Status: 410,
+			Chain: []jsErrorLink{{"*webview2.Error", "gone: missing"}, {"*errors.errorString", "missing"}},
+		}},
+		{"registration order", errors.Join(errTestLocked, errTestMissing), jsError{
+			Name: "GoError", Message: "locked\nmissing", Code: "missing",
+			Chain: []jsErrorLink{{"*errors.joinError", "locked\nmissing"}, {"*errors.errorString", "locked"}, {"*errors.errorString", "missing"}},
+		}},
+		{"status and data", fmt.Errorf("upload: %w", quotaError{3, 2}), jsError{
+			Name: "GoError", Message: "upload: quota exceeded", Status: 429,
+			Data:  map[string]int{"used": 3, "limit": 2},
+			Chain: []jsErrorLink{{"*fmt.wrapError", "upload: quota exceeded"}, {"webview2.quotaError", "quota exceeded"}},
+		}},
+		{"panic", &PanicError{CallInfo: CallInfo{Method: "f"}, Value: "boom"}, jsError{
+			Name: "GoPanic", Message: "panic in f: boom", Code: "panic",
+			Chain: []jsErrorLink{{"*webview2.PanicError", "panic in f: boom"}},
+		}},
+	} {
+		if got := newJSError(tt.err); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: newJSError = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGoError(t *testing.T) {
+	f := NewFake(WebViewOptions{})
+	if err := f.Bind("upload", func() error { return fmt.Errorf("upload: %w", quotaError{3, 2}) }); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Bind("bad", func() error { return &Error{Code: "bad", Data: make(chan int)} }); err != nil {
+		t.Fatal(err)
+	}
+	_, err := f.Call(t.Context(), "upload")
+	var e *Error
+	if !errors.As(err, &e) || e.Status != 429 || e.Message != "upload: quota exceeded" {
+		t.Fatalf("upload = %v, want its GoError", err)
+	}
+	if data, _ := json.Marshal(e.Data); string(data) != `{"limit":2,"used":3}` {
+		t.Errorf("data = %s", data)
+	}
+
+	// Data that cannot be marshaled is left out rather than failing the
+	// whole error.
+	_, err = f.Call(t.Context(), "bad")
+	if !errors.As(err, &e) || e.Code != "bad" || e.Data != nil {
+		t.Errorf("bad = %#v, want its code without data", err)
+	}
+}
@@ -0,0 +1,83 @@
+package webview2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+)
+
+// evalResult is what window._rpc.evaluate returns to ExecuteScript.
+type evalResult struct {
+	// Value is the JSON encoding of the result.
+	Value   *string  `json:"value"`
+	Error   *JSError `json:"error"`
+	Pending bool     `json:"pending"`
+}
+
+func (w *webview) EvalResult(ctx context.Context, js string) (json.RawMessage, error) {
+	if w.onUIThread() {
+		return nil, errors.New("EvalResult must not be called from the UI thread")
+	}
+	r := &JSResult{done: make(chan struct{})}
+	w.m.Lock()
+	id := w.addCallback(r)
+	w.m.Unlock()
+
+	w.recorder.record(TraceEntry{Kind: TraceEval, Script: js})
+	w.Dispatch(func() {
+		w.evaluate(id, js)
+	})
+
+	v, err := r.Wait(ctx)
+	if ctx.Err() != nil {
+		w.m.Lock()
+		delete(w.callbacks, id)
+		w.m.Unlock()
+	}
+	if err == nil && len(v) == 0 {
+		v = json.RawMessage("null")
+	}
+	return v, err
+}
+
+// evaluate runs js for the callback id on the UI thread. The script is
+// passed to window._rpc.evaluate as a string, which evaluates it once, so
+// that it cannot escape the call and its syntax errors are reported like
+// other exceptions.
+func (w *webview) evaluate(id int, js string) {
+	script := "window._rpc.evaluate(" + strconv.Itoa(id) + ", " + jsString(js) + ")"
+	w.browser.EvalWithResult(script, func(result string, err error) {
+		if err != nil {
+			w.settleCallbackResult(id, nil, err)
+			return
+		}
+		var res evalResult
+		if err := json.Unmarshal([]byte(result), &res); err != nil {
+			w.settleCallbackResult(id, nil, err)
+			return
+		}
+		switch {
+		case res.Pending:
+			// The result of the promise is posted as a message.
+		case res.Error != nil:
+			w.settleCallbackResult(id, nil, res.Error)
+		case res.Value != nil:
+			w.settleCallbackResult(id, json.RawMessage(*res.Value), nil)
+		default:
+			w.settleCallbackResult(id, nil, errors.New("script could not be evaluated"))
+		}
+	})
+}
+
+// EvalAs evaluates js like WebView.EvalResult and unmarshals the result into
+// a value of type T.
+func EvalAs[T any](ctx context.Context, w WebView, js string) (T, error) {
+	var v T
+	b, err := w.EvalResult(ctx, js)
+	if err != nil {
+		return v, err
+	}
+	err = json.Unmarshal(b, &v)
+	return v, err
+}
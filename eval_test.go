@@ -0,0 +1,70 @@
+package webview2
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestEvalResult(t *testing.T) {
+	f := NewFake(WebViewOptions{})
+	f.SetEvalResult(func(script string) (json.RawMessage, error) {
+		if script == "document.title" {
+			return json.RawMessage(`"title"`), nil
+		}
+		return nil, &JSError{Name: "ReferenceError", Message: script + " is not defined"}
+	})
+	title, err := EvalAs[string](t.Context(), f, "document.title")
+	if err != nil || title != "title" {
+		t.Errorf("EvalAs = %q, %v, want title", title, err)
+	}
+	_, err = f.EvalResult(t.Context(), "missing")
+	if jsErr, ok := err.(*JSError); !ok || jsErr.Name != "ReferenceError" {
+		t.Errorf("EvalResult error = %v, want a ReferenceError", err)
+	}
+}
+
+// evalBrowser records the scripts of EvalResult and answers them like
+// WebView2 with result.
+type evalBrowser struct {
+	browser
+	scripts *[]string
+	result  string
+}
+
+func (b evalBrowser) EvalWithResult(script string, done func(result string, err error)) {
+	*b.scripts = append(*b.scripts, script)
+	done(b.result, nil)
+}
+
+// TestEvalResultOnce checks that scripts are passed as strings, so that
+// they cannot escape window._rpc.evaluate, and are evaluated once even if
+// their value is null.
+func TestEvalResultOnce(t *testing.T) {
+	for _, js := range []string{"let x = 4; x * 2", "1); sideEffect(); (0", "null"} {
+		f := NewFake(WebViewOptions{})
+		var scripts []string
+		f.w.browser = evalBrowser{f.w.browser, &scripts, `{"value":"null"}`}
+		v, err := f.EvalResult(t.Context(), js)
+		if err != nil || string(v) != "null" {
+			t.Errorf("EvalResult(%q) = %s, %v, want null", js, v, err)
+		}
+		want := "window._rpc.evaluate(1, " + jsString(js) + ")"
+		if len(scripts) != 1 || scripts[0] != want {
+			t.Errorf("EvalResult(%q) evaluated %q, want %q", js, scripts, want)
+		}
+	}
+}
+
+func TestEvalResultNotEvaluated(t *testing.T) {
+	f := NewFake(WebViewOptions{})
+	var scripts []string
+	// WebView2 returns null for scripts that throw, e.g. because the
+	// runtime is missing.
+	f.w.browser = evalBrowser{f.w.browser, &scripts, "null"}
+	if _, err := f.EvalResult(t.Context(), "1"); err == nil {
+		t.Error("EvalResult succeeded without a result")
+	}
+	if len(scripts) != 1 {
+		t.Errorf("evaluated %q, want a single script", scripts)
+	}
+}
@@ -0,0 +1,152 @@
+package webview2
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"reflect"
+	"strings"
+)
+
+// eventHandler is a Go function subscribed to events with On or Once.
+type eventHandler struct {
+	pattern string
+	f       reflect.Value
+	once    bool
+}
+
+func (w *webview) Emit(topic string, payload interface{}) error {
+	m, err := w.message("emit", topic, payload)
+	if err != nil {
+		return err
+	}
+	w.recorder.recordValue(TraceEntry{Kind: TraceEmit, Topic: topic}, payload, nil)
+	w.Dispatch(func() {
+		w.deliver(m)
+	})
+	return nil
+}
+
+func (w *webview) On(pattern string, handler interface{}) (func(), error) {
+	return w.on(pattern, handler, false)
+}
+
+func (w *webview) Once(pattern string, handler interface{}) (func(), error) {
+	return w.on(pattern, handler, true)
+}
+
+func (w *webview) on(pattern string, handler interface{}, once bool) (func(), error) {
+	v := reflect.ValueOf(handler)
+	if v.Kind() != reflect.Func {
+		return nil, errors.New("event handler must be a function")
+	}
+	t := v.Type()
+	if t.NumIn() > 2 || t.NumIn() == 2 && t.In(0).Kind() != reflect.String || t.IsVariadic() {
+		return nil, errors.New("event handler must take the payload, optionally preceded by the topic")
+	}
+	if t.NumOut() > 1 || t.NumOut() == 1 && t.Out(0) != errorType {
+		return nil, errors.New("event handler may only return an error")
+	}
+	h := &eventHandler{pattern: pattern, f: v, once: once}
+	w.m.Lock()
+	w.handlers = append(w.handlers, h)
+	w.m.Unlock()
+	return func() { w.removeHandlers(func(x *eventHandler) bool { return x == h }) }, nil
+}
+
+func (w *webview) Off(pattern string) {
+	w.removeHandlers(func(h *eventHandler) bool { return h.pattern == pattern })
+}
+
+func (w *webview) removeHandlers(match func(*eventHandler) bool) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	handlers := w.handlers[:0:0]
+	for _, h := range w.handlers {
+		if !match(h) {
+			handlers = append(handlers, h)
+		}
+	}
+	w.handlers = handlers
+}
+
+// dispatchEvent calls the Go handlers of an event emitted by JavaScript from
+// the document at source.
+func (w *webview) dispatchEvent(topic string, payload json.RawMessage, source string) {
+	w.m.Lock()
+	var matched []*eventHandler
+	handlers := w.handlers[:0:0]
+	for _, h := range w.handlers {
+		if matchTopic(h.pattern, topic) {
+			matched = append(matched, h)
+			if h.once {
+				continue
+			}
+		}
+		handlers = append(handlers, h)
+	}
+	w.handlers = handlers
+	w.m.Unlock()
+
+	if len(payload) == 0 {
+		payload, _ = w.codec.Marshal(nil)
+	}
+	info := w.callInfo("event "+topic, source, 0)
+	for _, h := range matched {
+		w.executor.execute(info.Method, func() {
+			w.callHandler(h, info, topic, payload)
+		})
+	}
+}
+
+// callHandler calls h with an event. Like those of bound functions, panics
+// are recovered and reported, with info describing the event.
+func (w *webview) callHandler(h *eventHandler, info CallInfo, topic string, payload json.RawMessage) {
+	var err error
+	func() {
+		defer w.recoverPanic(info, &err)
+		err = h.call(w.codec, topic, payload)
+	}()
+	var p *PanicError
+	if err != nil && !errors.As(err, &p) {
+		log.Printf("event handler for %s failed: %v", topic, err)
+	}
+}
+
+func (h *eventHandler) call(c Codec, topic string, payload json.RawMessage) error {
+	t := h.f.Type()
+	var args []reflect.Value
+	if t.NumIn() == 2 {
+		args = append(args, reflect.ValueOf(topic).Convert(t.In(0)))
+	}
+	if t.NumIn() > 0 {
+		arg := reflect.New(t.In(t.NumIn() - 1))
+		if err := unmarshalParam(c, payload, arg); err != nil {
+			return err
+		}
+		args = append(args, arg.Elem())
+	}
+	res := h.f.Call(args)
+	if len(res) == 1 && !res[0].IsNil() {
+		return res[0].Interface().(error)
+	}
+	return nil
+}
+
+// matchTopic reports whether topic matches pattern. Topics are made of
+// segments separated by dots. In a pattern, "*" matches any one segment, and
+// "**" as the last segment matches any number of remaining ones. The same
+// rules are implemented by window.go.on.
+func matchTopic(pattern, topic string) bool {
+	p := strings.Split(pattern, ".")
+	t := strings.Split(topic, ".")
+	for i, seg := range p {
+		if seg == "**" && i == len(p)-1 {
+			return true
+		}
+		if i >= len(t) || seg != "*" && seg != t[i] {
+			return false
+		}
+	}
+	return len(p) == len(t)
+}
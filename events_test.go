@@ -0,0 +1,72 @@
+package webview2
+
+import "testing"
+
+func TestEventHandlerPanic(t *testing.T) {
+	var panics []*PanicError
+	f := NewFake(WebViewOptions{OnBindingPanic: func(err *PanicError) { panics = append(panics, err) }})
+	var got []string
+	if _, err := f.On("job.*", func(topic string, v string) { got = append(got, topic+"="+v) }); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.On("job.failed", func(v string) { panic("boom") }); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.EmitFromJS("job.failed", "x"); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.EmitFromJS("job.done", "y"); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"job.failed=x", "job.done=y"}; !equalStrings(got, want) {
+		t.Errorf("handled %q, want %q", got, want)
+	}
+	if len(panics) != 1 || panics[0].Method != "event job.failed" || panics[0].Value != "boom" {
+		t.Errorf("panics = %v, want one in event job.failed", panics)
+	}
+}
+
+func TestEmit(t *testing.T) {
+	for _, codec := range []Codec{JSONCodec, MessagePackCodec, CBORCodec} {
+		f := NewFake(WebViewOptions{Codec: codec})
+		if err := f.Emit("saved", map[string]int{"n": 1}); err != nil {
+			t.Fatal(err)
+		}
+		got := f.Emitted("saved")
+		if len(got) != 1 || string(got[0]) != `{"n":1}` {
+			t.Errorf("%s: emitted %s", codec.Name(), got)
+		}
+	}
+}
+
+func TestMatchTopic(t *testing.T) {
+	for _, tt := range []struct {
+		pattern, topic string
+		want           bool
+	}{
+		{"a.b", "a.b", true},
+		{"a.b", "a.c", false},
+		{"a.*", "a.b", true},
+		{"a.*", "a.b.c", false},
+		{"a.**", "a.b.c", true},
+		{"a.**", "a", true},
+		{"*.b", "a.b", true},
+		{"a.**.c", "a.b.c", false},
+	} {
+		if got := matchTopic(tt.pattern, tt.topic); got != tt.want {
+			t.Errorf("matchTopic(%q, %q) = %v, want %v", tt.pattern, tt.topic, got, tt.want)
+		}
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
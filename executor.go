@@ -0,0 +1,110 @@
+package webview2
+
+import (
+	"runtime"
+	"sync"
+)
+
+// BindingExecution selects how functions registered with Bind are executed
+// when they are called from JavaScript.
+type BindingExecution int
+
+const (
+	// BindingExecutionSync runs bound functions on the UI thread. The window
+	// does not process any messages until the function returns. This is the
+	// default.
+	BindingExecutionSync BindingExecution = iota
+
+	// BindingExecutionGoroutine runs every call in a new goroutine.
+	BindingExecutionGoroutine
+
+	// BindingExecutionPool runs calls on a bounded pool of goroutines. Calls
+	// are queued while all workers are busy. See WebViewOptions.BindingWorkers.
+	BindingExecutionPool
+
+	// BindingExecutionSerial runs calls off the UI thread, but calls to the
+	// same binding are executed one at a time in the order they arrived.
+	// Calls to different bindings may run concurrently.
+	BindingExecutionSerial
+)
+
+// executor runs binding calls according to a BindingExecution.
+type executor interface {
+	execute(name string, f func())
+}
+
+func newExecutor(kind BindingExecution, workers int) executor {
+	switch kind {
+	case BindingExecutionGoroutine:
+		return goroutineExecutor{}
+	case BindingExecutionPool:
+		if workers <= 0 {
+			workers = runtime.NumCPU()
+		}
+		return &workQueue{limit: workers}
+	case BindingExecutionSerial:
+		return &serialExecutor{queues: map[string]*workQueue{}}
+	default:
+		return syncExecutor{}
+	}
+}
+
+type syncExecutor struct{}
+
+func (syncExecutor) execute(_ string, f func()) { f() }
+
+type goroutineExecutor struct{}
+
+func (goroutineExecutor) execute(_ string, f func()) { go f() }
+
+// workQueue runs queued functions on at most limit goroutines. Goroutines are
+// started on demand and exit once the queue is drained.
+type workQueue struct {
+	m       sync.Mutex
+	limit   int
+	running int
+	jobs    []func()
+}
+
+func (q *workQueue) execute(_ string, f func()) {
+	q.m.Lock()
+	q.jobs = append(q.jobs, f)
+	if q.running < q.limit {
+		q.running++
+		go q.work()
+	}
+	q.m.Unlock()
+}
+
+func (q *workQueue) work() {
+	for {
+		q.m.Lock()
+		if len(q.jobs) == 0 {
+			q.running--
+			q.m.Unlock()
+			return
+		}
+		f := q.jobs[0]
+		q.jobs[0] = nil
+		q.jobs = q.jobs[1:]
+		q.m.Unlock()
+		f()
+	}
+}
+
+// serialExecutor keeps a single-worker queue per binding name.
+type serialExecutor struct {
+	m      sync.Mutex
+	queues map[string]*workQueue
+}
+
+func (e *serialExecutor) execute(name string, f func()) {
+	e.m.Lock()
+	q, ok := e.queues[name]
+	if !ok {
+		q = &workQueue{limit: 1}
+		e.queues[name] = q
+	}
+	e.m.Unlock()
+	q.execute(name, f)
+}
@@ -0,0 +1,123 @@
+package webview2
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPoolLimit(t *testing.T) {
+	e := newExecutor(BindingExecutionPool, 2)
+	var m sync.Mutex
+	running, peak := 0, 0
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		e.execute("f", func() {
+			defer wg.Done()
+			m.Lock()
+			running++
+			peak = max(peak, running)
+			m.Unlock()
+			<-release
+			m.Lock()
+			running--
+			m.Unlock()
+		})
+	}
+	// Wait until the workers took their first jobs.
+	for {
+		m.Lock()
+		n := running
+		m.Unlock()
+		if n == 2 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+	if peak != 2 {
+		t.Errorf("%d calls ran at once, want 2", peak)
+	}
+	// Workers exit once the queue is drained.
+	q := e.(*workQueue)
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+		q.m.Lock()
+		n := q.running
+		q.m.Unlock()
+		if n == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d workers left after the queue drained", n)
+		}
+	}
+}
+
+func TestSerialOrder(t *testing.T) {
+	e := newExecutor(BindingExecutionSerial, 0)
+	var m sync.Mutex
+	var order []int
+	bRan := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(11)
+	e.execute("a", func() {
+		defer wg.Done()
+		// Calls of other bindings are not held up by a.
+		<-bRan
+	})
+	for i := 0; i < 10; i++ {
+		e.execute("a", func() {
+			defer wg.Done()
+			m.Lock()
+			order = append(order, i)
+			m.Unlock()
+		})
+	}
+	e.execute("b", func() { close(bRan) })
+	wg.Wait()
+	if len(order) != 10 {
+		t.Fatalf("%d calls of a ran, want 10", len(order))
+	}
+	for i, n := range order {
+		if n != i {
+			t.Fatalf("calls of a ran in order %v", order)
+		}
+	}
+}
+
+// TestBindingWorkers checks that the pool of a WebView has
+// WebViewOptions.BindingWorkers workers.
+func TestBindingWorkers(t *testing.T) {
+	f := NewFake(WebViewOptions{BindingExecution: BindingExecutionPool, BindingWorkers: 1})
+	started := make(chan string, 2)
+	release := make(chan struct{})
+	if err := f.Bind("block", func(name string) {
+		started <- name
+		<-release
+	}); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 2)
+	for _, name := range []string{"first", "second"} {
+		go func() {
+			_, err := f.Call(context.Background(), "block", name)
+			done <- err
+		}()
+	}
+	<-started
+	select {
+	case name := <-started:
+		t.Errorf("%s call started while the only worker was busy", name)
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(release)
+	for i := 0; i < 2; i++ {
+		if err := <-done; err != nil {
+			t.Error(err)
+		}
+	}
+}
@@ -0,0 +1,620 @@
+package webview2
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"hash/crc32"
+	"log"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// fakeURL is the URL of the document of a Fake until it navigates.
+const fakeURL = "about:blank"
+
+var (
+	errNoEvalResult = errors.New("no result is set for scripts evaluated by the fake")
+
+	// errCorruptMessage is what the JavaScript runtime rejects calls with
+	// whose messages fail their integrity check.
+	errCorruptMessage = errors.New("a message from Go failed its integrity check")
+
+	// errUndeliverable is what the JavaScript runtime rejects calls with
+	// whose messages Go failed to post.
+	errUndeliverable = errors.New("a message from Go could not be delivered")
+)
+
+// Fake is a WebView without a window or browser, for unit tests of code
+// built on WebView. It runs on every platform. Bindings, middleware, events
+// and options work as with WebView2, but the document is simulated: the
+// Fake records navigations and scripts, and tests post the messages
+// JavaScript would post with Call, EmitFromJS and Post.
+//
+// Run blocks until Terminate or Destroy is called, and Dispatch runs
+// functions immediately on the calling goroutine.
+type Fake struct {
+	WebView
+
+	w *webview
+
+	m           sync.Mutex
+	url         string
+	html        string
+	navigations []string
+	inits       []string
+	posted      []string
+	trace       []TraceEntry
+	evalResult  func(script string) (json.RawMessage, error)
+
+	// waiting holds the calls of Call until their promise is settled, and
+	// transfers the messages being received in chunks, by delivery.
+	waiting   map[int]chan error
+	transfers map[uint64]*fakeTransfer
+	abandoned map[uint64]bool
+
+	// deliveries holds the deliveries waiting for earlier ones, which are
+	// run in order like window._rpc.ordered does. draining is set while
+	// one goroutine runs them.
+	nextDelivery uint64
+	deliveries   map[uint64]func()
+	draining     bool
+}
+
+// fakeTransfer is a message being received in chunks.
+type fakeTransfer struct {
+	parts    []string
+	received int
+	failed   bool
+}
+
+// NewFake creates a Fake using the provided options. Options that concern
+// the window or WebView2, such as WindowOptions and DataPath, are ignored.
+// Like NewWithOptions, it returns nil if the options are invalid.
+func NewFake(options WebViewOptions) *Fake {
+	f := &Fake{
+		url:        fakeURL,
+		waiting:    map[int]chan error{},
+		transfers:  map[uint64]*fakeTransfer{},
+		abandoned:  map[uint64]bool{},
+		deliveries: map[uint64]func(){},
+	}
+	w, err := newWebview(options)
+	if err != nil {
+		log.Printf("failed to create fake: %v", err)
+		return nil
+	}
+	w.headless = true
+	w.browser = fakeBrowser{f}
+	if w.recorder == nil {
+		w.recorder = &recorder{}
+	}
+	w.recorder.sink = f.record
+	f.w = w
+	f.WebView = w
+	return f
+}
+
+func (f *Fake) record(e TraceEntry) {
+	if e.Kind == TraceMessage {
+		return
+	}
+	f.m.Lock()
+	defer f.m.Unlock()
+	f.trace = append(f.trace, e)
+}
+
+// URL returns the URL of the current document: the last URL navigated to,
+// or "about:blank" after SetHtml.
+func (f *Fake) URL() string {
+	f.m.Lock()
+	defer f.m.Unlock()
+	return f.url
+}
+
+// HTML returns the content last set with SetHtml.
+func (f *Fake) HTML() string {
+	f.m.Lock()
+	defer f.m.Unlock()
+	return f.html
+}
+
+// Navigations returns the URLs navigated to, in order.
+func (f *Fake) Navigations() []string {
+	f.m.Lock()
+	defer f.m.Unlock()
+	return append([]string(nil), f.navigations...)
+}
+
+// InitScripts returns the scripts passed to Init.
+func (f *Fake) InitScripts() []string {
+	f.m.Lock()
+	defer f.m.Unlock()
+	return append([]string(nil), f.inits...)
+}
+
+// Evals returns the scripts passed to Eval and EvalResult, in order.
+// Scripts of the bridge itself, such as those delivering results, are not
+// included.
+func (f *Fake) Evals() []string {
+	var scripts []string
+	for _, e := range f.Trace() {
+		if e.Kind == TraceEval {
+			scripts = append(scripts, e.Script)
+		}
+	}
+	return scripts
+}
+
+// Emitted returns the payloads of the events emitted with topic, encoded
+// as JSON.
+func (f *Fake) Emitted(topic string) []json.RawMessage {
+	var payloads []json.RawMessage
+	for _, e := range f.Trace() {
+		if e.Kind == TraceEmit && e.Topic == topic {
+			payloads = append(payloads, e.Value)
+		}
+	}
+	return payloads
+}
+
+// Posted returns the messages posted to the document, such as JSON-RPC
+// responses.
+func (f *Fake) Posted() []string {
+	f.m.Lock()
+	defer f.m.Unlock()
+	return append([]string(nil), f.posted...)
+}
+
+// Trace returns the traffic of the bridge as it would be written to
+// WebViewOptions.Recorder, except for the messages posted by the test.
+func (f *Fake) Trace() []TraceEntry {
+	f.m.Lock()
+	defer f.m.Unlock()
+	return append([]TraceEntry(nil), f.trace...)
+}
+
+// SetEvalResult sets the function that evaluates the scripts passed to
+// EvalResult. It returns the JSON encoding of the result, or an error such
+// as a *JSError to reject with. Without it, EvalResult fails.
+func (f *Fake) SetEvalResult(eval func(script string) (json.RawMessage, error)) {
+	f.m.Lock()
+	f.evalResult = eval
+	f.m.Unlock()
+}
+
+// Post delivers message to the bridge as if the current document had
+// posted it with window.chrome.webview.postMessage.
+func (f *Fake) Post(message string) {
+	f.w.msgcb(message, f.URL())
+}
+
+// Call calls the function bound as method from the current document, as
+// JavaScript would, and waits until its result is delivered to the
+// document. args are encoded with the codec of the Fake. If the function
+// fails, the error is an *Error with the code, message, status and data
+// JavaScript would see, or a *PanicError if it panicked. The items of a
+// streaming result are returned as a JSON array. Results large enough to be
+// posted in chunks are reassembled and checked like the JavaScript runtime
+// does. Calls have negative IDs, like JSON-RPC calls.
+//
+// If ctx is done before the result is delivered, the call is aborted as
+// JavaScript would abort it. With BindingExecutionSync, however, the
+// function runs on the calling goroutine as it would on the UI thread, so
+// ctx is only checked before the call is made.
+func (f *Fake) Call(ctx context.Context, method string, args ...interface{}) (json.RawMessage, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if args == nil {
+		args = []interface{}{}
+	}
+	id := f.w.nextInternalID()
+
+	done := make(chan error, 1)
+	f.m.Lock()
+	f.waiting[id] = done
+	f.m.Unlock()
+	defer func() {
+		f.m.Lock()
+		delete(f.waiting, id)
+		f.m.Unlock()
+	}()
+
+	msg, err := f.encode(struct {
+		ID     int           `json:"id"`
+		Method string        `json:"method"`
+		Params []interface{} `json:"params"`
+		Credit int           `json:"credit"`
+	}{id, method, args, math.MaxInt32})
+	if err != nil {
+		return nil, err
+	}
+	f.Post(msg)
+
+	// A result delivered while the call was posted, e.g. by a synchronous
+	// binding, takes precedence over ctx.
+	select {
+	case err = <-done:
+	default:
+		select {
+		case err = <-done:
+		case <-ctx.Done():
+			if msg, err := f.encode(struct {
+				ID     int  `json:"id"`
+				Cancel bool `json:"cancel"`
+			}{id, true}); err == nil {
+				f.Post(msg)
+			}
+			return nil, ctx.Err()
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	// The response was recorded before it was delivered.
+	var res TraceEntry
+	var items []json.RawMessage
+	for _, e := range f.Trace() {
+		switch {
+		case e.ID != id:
+		case e.Kind == TraceResponse:
+			res = e
+		case e.Kind == TraceItem:
+			items = append(items, e.Value)
+		}
+	}
+	if res.Error != nil {
+		var e jsError
+		if err := json.Unmarshal(res.Error, &e); err != nil {
+			return nil, err
+		}
+		if e.Name == "GoPanic" {
+			// The stack and the value passed to panic stay in Go.
+			return nil, &PanicError{
+				CallInfo: CallInfo{Method: method, Source: f.URL(), CallID: id},
+				Value:    strings.TrimPrefix(e.Message, "panic in "+method+": "),
+			}
+		}
+		return nil, &Error{Code: e.Code, Message: e.Message, Status: e.Status, Data: e.Data}
+	}
+	if items != nil {
+		return json.Marshal(items)
+	}
+	return res.Value, nil
+}
+
+// EmitFromJS emits an event as if the current document had called
+// window.go.emit(topic, payload). Events emitted by Go are reported by
+// Emitted.
+func (f *Fake) EmitFromJS(topic string, payload interface{}) error {
+	msg, err := f.encode(struct {
+		Event   string      `json:"event"`
+		Payload interface{} `json:"payload"`
+	}{topic, payload})
+	if err != nil {
+		return err
+	}
+	f.Post(msg)
+	return nil
+}
+
+// encode encodes a message of the JavaScript runtime with the codec.
+func (f *Fake) encode(v interface{}) (string, error) {
+	b, err := f.w.codec.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	if f.w.usesJSON() {
+		return string(b), nil
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+// receive handles a message the bridge delivered to the document, decoded
+// into the op of window._rpc and its arguments. Settling a call ends its
+// Call.
+func (f *Fake) receive(msg []interface{}) {
+	if len(msg) < 2 {
+		return
+	}
+	switch op, _ := msg[0].(string); op {
+	case "resolve", "fail":
+		if id, ok := toInt(msg[1]); ok {
+			f.settle(id, nil)
+		}
+	case "abandon":
+		seq, _ := toInt(msg[1])
+		f.m.Lock()
+		delete(f.transfers, uint64(seq))
+		f.abandoned[uint64(seq)] = true
+		f.m.Unlock()
+		if len(msg) > 2 {
+			if id, ok := toInt(msg[2]); ok {
+				f.settle(id, errUndeliverable)
+			}
+		}
+	}
+}
+
+// ordered runs delivery seq once the deliveries before it ran, like
+// window._rpc.ordered.
+func (f *Fake) ordered(seq uint64, fn func()) {
+	f.m.Lock()
+	if f.nextDelivery == 0 {
+		f.nextDelivery = seq
+	}
+	if seq < f.nextDelivery {
+		f.m.Unlock()
+		fn()
+		return
+	}
+	f.deliveries[seq] = fn
+	if f.draining {
+		// The goroutine running earlier deliveries runs this one too.
+		f.m.Unlock()
+		return
+	}
+	f.draining = true
+	for {
+		next, ok := f.deliveries[f.nextDelivery]
+		if !ok {
+			break
+		}
+		delete(f.deliveries, f.nextDelivery)
+		f.nextDelivery++
+		f.m.Unlock()
+		next()
+		f.m.Lock()
+	}
+	f.draining = false
+	f.m.Unlock()
+}
+
+// decodePayload decodes a message given as the JSON array [op, args...]
+// or, with a binary codec, base64-encoded.
+func (f *Fake) decodePayload(payload string) ([]interface{}, bool) {
+	data := []byte(payload)
+	if !f.w.usesJSON() {
+		var err error
+		if data, err = base64.StdEncoding.DecodeString(payload); err != nil {
+			return nil, false
+		}
+	}
+	var msg []interface{}
+	if f.w.codec.Unmarshal(data, &msg) != nil {
+		return nil, false
+	}
+	return msg, true
+}
+
+// settle ends the Call of call id, if any, with err.
+func (f *Fake) settle(id int, err error) {
+	f.m.Lock()
+	done, ok := f.waiting[id]
+	delete(f.waiting, id)
+	f.m.Unlock()
+	if ok {
+		done <- err
+	}
+}
+
+// receiveScript handles the scripts of the bridge. Those delivering
+// messages are passed to receive; others, like those installing bindings,
+// are ignored.
+func (f *Fake) receiveScript(script string) {
+	if rest, ok := strings.CutPrefix(script, "window._rpc.ordered("); ok {
+		n, _, _ := strings.Cut(rest, ",")
+		if seq, err := strconv.ParseUint(n, 10, 64); err == nil {
+			f.ordered(seq, func() {
+				if msg, ok := f.scriptMessage(script); ok {
+					f.receive(msg)
+				}
+			})
+			return
+		}
+	}
+	if msg, ok := f.scriptMessage(script); ok {
+		f.receive(msg)
+	}
+}
+
+// scriptMessage decodes the message a script of the bridge delivers.
+func (f *Fake) scriptMessage(script string) ([]interface{}, bool) {
+	if rest, ok := strings.CutPrefix(script, "window._rpc.ordered("); ok {
+		_, script, _ = strings.Cut(rest, ", function() {")
+		script = strings.TrimSuffix(script, "})")
+	}
+	if data, ok := strings.CutPrefix(script, `window._rpc.recv("`); ok {
+		return f.decodePayload(strings.TrimSuffix(data, `")`))
+	}
+	// Other calls pass their arguments as JSON.
+	call, ok := strings.CutPrefix(script, "window._rpc.")
+	if !ok {
+		return nil, false
+	}
+	op, args, ok := strings.Cut(call, "(")
+	if !ok {
+		return nil, false
+	}
+	var msg []interface{}
+	if json.Unmarshal([]byte("["+jsString(op)+","+strings.TrimSuffix(args, ")")+"]"), &msg) != nil {
+		return nil, false
+	}
+	return msg, true
+}
+
+// receiveChunk reassembles messages posted in chunks, like receiveChunk in
+// rpc.js. A corrupt chunk fails the call its message belongs to.
+func (f *Fake) receiveChunk(c chunk) {
+	f.m.Lock()
+	if f.nextDelivery == 0 {
+		f.nextDelivery = c.Seq
+	}
+	if f.abandoned[c.Seq] {
+		f.m.Unlock()
+		return
+	}
+	t, ok := f.transfers[c.Seq]
+	if !ok {
+		t = &fakeTransfer{}
+		f.transfers[c.Seq] = t
+	}
+	if t.failed {
+		f.m.Unlock()
+		return
+	}
+	if crc32.ChecksumIEEE([]byte(c.Data)) != c.CRC || c.Index < 0 || c.Index >= c.Count {
+		t.failed = true
+		t.parts = nil
+		f.m.Unlock()
+		f.ordered(c.Seq, func() { f.settle(c.Call, errCorruptMessage) })
+		return
+	}
+	if t.parts == nil {
+		t.parts = make([]string, c.Count)
+	}
+	if t.parts[c.Index] == "" {
+		t.parts[c.Index] = c.Data
+		t.received++
+	}
+	if t.received < c.Count {
+		f.m.Unlock()
+		return
+	}
+	delete(f.transfers, c.Seq)
+	f.m.Unlock()
+	f.ordered(c.Seq, func() {
+		if msg, ok := f.decodePayload(strings.Join(t.parts, "")); ok {
+			f.receive(msg)
+		}
+	})
+}
+
+// toInt converts a number decoded by a codec to an int.
+func toInt(v interface{}) (int, bool) {
+	rv := reflect.ValueOf(v)
+	switch {
+	case rv.CanInt():
+		return int(rv.Int()), true
+	case rv.CanUint():
+		return int(rv.Uint()), true
+	case rv.CanFloat():
+		return int(rv.Float()), true
+	}
+	return 0, false
+}
+
+// navigate starts a new document like ContentLoadingCallback does.
+func (f *Fake) navigate(url, html string) {
+	f.w.m.Lock()
+	id := f.w.navigationID + 1
+	f.w.m.Unlock()
+	f.w.documentLoading(id, url)
+	f.m.Lock()
+	f.url = url
+	f.html = html
+	f.navigations = append(f.navigations, url)
+	// The runtime of the new document starts afresh.
+	f.nextDelivery = 0
+	f.deliveries = map[uint64]func(){}
+	f.transfers = map[uint64]*fakeTransfer{}
+	f.abandoned = map[uint64]bool{}
+	f.m.Unlock()
+}
+
+// fakeBrowser is the browser of a Fake.
+type fakeBrowser struct {
+	f *Fake
+}
+
+func (b fakeBrowser) Embed(hwnd uintptr) bool { return true }
+func (b fakeBrowser) Resize()                 {}
+func (b fakeBrowser) Navigate(url string)     { b.f.navigate(url, "") }
+func (b fakeBrowser) NavigateToString(html string) {
+	b.f.navigate(fakeURL, html)
+}
+
+func (b fakeBrowser) Init(script string) {
+	b.f.m.Lock()
+	b.f.inits = append(b.f.inits, script)
+	b.f.m.Unlock()
+}
+
+func (b fakeBrowser) AddInitScript(script string, done func(id string, err error)) {
+	done("", nil)
+}
+
+func (b fakeBrowser) RemoveInitScript(id string) error { return nil }
+func (b fakeBrowser) Eval(script string)               { b.f.receiveScript(script) }
+
+// PostMessageJSON receives the chunks of the bridge.
+func (b fakeBrowser) PostMessageJSON(data string) error {
+	var m map[string]chunk
+	if err := json.Unmarshal([]byte(data), &m); err != nil {
+		return err
+	}
+	if c, ok := m["$rpcChunk"]; ok {
+		b.f.receiveChunk(c)
+	}
+	return nil
+}
+
+func (b fakeBrowser) PostMessage(message string) {
+	b.f.m.Lock()
+	b.f.posted = append(b.f.posted, message)
+	b.f.m.Unlock()
+}
+
+// EvalWithResult answers the scripts of EvalResult, which pass the source
+// of the script to window._rpc.evaluate as a string.
+func (b fakeBrowser) EvalWithResult(script string, done func(result string, err error)) {
+	b.f.m.Lock()
+	eval := b.f.evalResult
+	b.f.m.Unlock()
+	args := strings.TrimSuffix(strings.TrimPrefix(script, "window._rpc.evaluate("), ")")
+	_, src, _ := strings.Cut(args, ", ")
+	var js string
+	if err := json.Unmarshal([]byte(src), &js); err != nil {
+		done("", err)
+		return
+	}
+	if eval == nil {
+		done("", errNoEvalResult)
+		return
+	}
+	var res evalResult
+	v, err := eval(js)
+	if err != nil {
+		var jsErr *JSError
+		if !errors.As(err, &jsErr) {
+			jsErr = &JSError{Message: err.Error()}
+		}
+		res.Error = jsErr
+	} else {
+		if v == nil {
+			v = json.RawMessage("null")
+		}
+		s := string(v)
+		res.Value = &s
+	}
+	out, err := json.Marshal(res)
+	done(string(out), err)
+}
+
+func (b fakeBrowser) NotifyParentWindowPositionChanged() error { return nil }
+func (b fakeBrowser) Focus()                                   {}
+
+func (w *webview) terminateHeadless() {
+	w.terminate.Do(func() { close(w.done) })
+}
+
+// destroyHeadless does what destroying the window does.
+func (w *webview) destroyHeadless() {
+	w.cancelPending()
+	w.terminateHeadless()
+}
@@ -0,0 +1,89 @@
+package webview2
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestFakeCallPanic(t *testing.T) {
+	f := NewFake(WebViewOptions{})
+	if err := f.Bind("boom", func() { panic("boom") }); err != nil {
+		t.Fatal(err)
+	}
+	_, err := f.Call(t.Context(), "boom")
+	var p *PanicError
+	if !errors.As(err, &p) {
+		t.Fatalf("Call = %v, want a *PanicError", err)
+	}
+	if p.Method != "boom" || p.Value != "boom" || p.ErrorCode() != "panic" || err.Error() != "panic in boom: boom" {
+		t.Errorf("Call = %#v", p)
+	}
+}
+
+func TestFakeCallIDs(t *testing.T) {
+	f := NewFake(WebViewOptions{JSONRPC: true})
+	var ids []int
+	if err := f.Bind("id", func(info CallInfo) int {
+		ids = append(ids, info.CallID)
+		return info.CallID
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Call(t.Context(), "id"); err != nil {
+		t.Fatal(err)
+	}
+	f.Post(`{"jsonrpc": "2.0", "method": "id", "id": 1}`)
+	if _, err := f.Call(t.Context(), "id"); err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 3 || ids[0] >= 0 || ids[0] == ids[1] || ids[1] == ids[2] || ids[0] == ids[2] {
+		t.Errorf("call IDs = %v, want distinct negative IDs", ids)
+	}
+}
+
+func TestFakeCallContext(t *testing.T) {
+	t.Run("sync", func(t *testing.T) {
+		f := NewFake(WebViewOptions{})
+		ctx, cancel := context.WithCancel(t.Context())
+		calls := 0
+		if err := f.Bind("f", func() int {
+			calls++
+			cancel()
+			return calls
+		}); err != nil {
+			t.Fatal(err)
+		}
+		// The function ran to completion on the calling goroutine, so its
+		// result is returned although ctx is done.
+		if res, err := f.Call(ctx, "f"); err != nil || string(res) != "1" {
+			t.Errorf("Call = %s, %v", res, err)
+		}
+		if _, err := f.Call(ctx, "f"); err != context.Canceled || calls != 1 {
+			t.Errorf("Call with a done context = %v after %d calls", err, calls)
+		}
+	})
+	t.Run("goroutine", func(t *testing.T) {
+		f := NewFake(WebViewOptions{BindingExecution: BindingExecutionGoroutine})
+		started := make(chan struct{})
+		aborted := make(chan error, 1)
+		if err := f.Bind("f", func(ctx context.Context) {
+			close(started)
+			<-ctx.Done()
+			aborted <- ctx.Err()
+		}); err != nil {
+			t.Fatal(err)
+		}
+		ctx, cancel := context.WithCancel(t.Context())
+		go func() {
+			<-started
+			cancel()
+		}()
+		if _, err := f.Call(ctx, "f"); err != context.Canceled {
+			t.Errorf("Call = %v, want %v", err, context.Canceled)
+		}
+		if err := <-aborted; err != context.Canceled {
+			t.Errorf("context of the function = %v, want %v", err, context.Canceled)
+		}
+	})
+}
@@ -0,0 +1,69 @@
+// Package bindtype classifies the parameter and result types of bound
+// functions. It is shared by go-webview2 and package tsgen, so that the
+// declarations tsgen generates match what the bridge does.
+package bindtype
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+)
+
+// webviewPath is the import path of the package declaring Bytes.
+const webviewPath = "github.com/logicossoftware/go-webview2"
+
+var (
+	marshalerType       = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	unmarshalerType     = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// IsStream reports whether values of type t are sent to JavaScript as an
+// async iterator. This is the case for channels that can be received from
+// and for functions shaped like iter.Seq.
+func IsStream(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Chan:
+		return t.ChanDir()&reflect.RecvDir != 0
+	case reflect.Func:
+		if t.NumIn() != 1 || t.NumOut() != 0 {
+			return false
+		}
+		yield := t.In(0)
+		return yield.Kind() == reflect.Func && yield.NumIn() == 1 && yield.NumOut() == 1 && yield.Out(0).Kind() == reflect.Bool
+	default:
+		return false
+	}
+}
+
+// StreamElem returns the type of the items of the stream type t.
+func StreamElem(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Chan {
+		return t.Elem()
+	}
+	return t.In(0).In(0)
+}
+
+// IsBytes reports whether t is webview2.Bytes.
+func IsBytes(t reflect.Type) bool {
+	return t.PkgPath() == webviewPath && t.Name() == "Bytes"
+}
+
+// IsBinary reports whether parameters and results of type t are exchanged
+// as binary data. This is the case for webview2.Bytes and for byte slices
+// that do not customize their JSON encoding, like json.RawMessage does.
+func IsBinary(t reflect.Type) bool {
+	if t.Kind() != reflect.Slice || t.Elem().Kind() != reflect.Uint8 || t.Elem().PkgPath() != "" {
+		return false
+	}
+	if IsBytes(t) {
+		return true
+	}
+	for _, iface := range []reflect.Type{marshalerType, unmarshalerType, textMarshalerType, textUnmarshalerType} {
+		if t.Implements(iface) || reflect.PointerTo(t).Implements(iface) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,60 @@
+package bindtype_test
+
+import (
+	"encoding/json"
+	"iter"
+	"net"
+	"reflect"
+	"testing"
+
+	webview2 "github.com/logicossoftware/go-webview2"
+	"github.com/logicossoftware/go-webview2/internal/bindtype"
+)
+
+type blob []byte
+
+func TestIsBinary(t *testing.T) {
+	for _, tt := range []struct {
+		v    interface{}
+		want bool
+	}{
+		{[]byte(nil), true},
+		{blob(nil), true},
+		{webview2.Bytes(nil), true},
+		{json.RawMessage(nil), false},
+		{net.IP(nil), false},
+		{[]int8(nil), false},
+		{"", false},
+	} {
+		if got := bindtype.IsBinary(reflect.TypeOf(tt.v)); got != tt.want {
+			t.Errorf("IsBinary(%T) = %v, want %v", tt.v, got, tt.want)
+		}
+	}
+	if !bindtype.IsBytes(reflect.TypeOf(webview2.Bytes(nil))) || bindtype.IsBytes(reflect.TypeOf(blob(nil))) {
+		t.Error("IsBytes does not identify webview2.Bytes")
+	}
+}
+
+func TestIsStream(t *testing.T) {
+	for _, tt := range []struct {
+		v    interface{}
+		elem interface{} // a value of the item type, or nil for no stream
+	}{
+		{make(<-chan int), 0},
+		{make(chan string), ""},
+		{make(chan<- int), nil},
+		{iter.Seq[float64](nil), 0.0},
+		{func(func(int) bool) {}, 0},
+		{func(func(int)) {}, nil},
+		{func(func(int) bool) int { return 0 }, nil},
+		{iter.Seq2[int, int](nil), nil},
+		{0, nil},
+	} {
+		typ := reflect.TypeOf(tt.v)
+		if got := bindtype.IsStream(typ); got != (tt.elem != nil) {
+			t.Errorf("IsStream(%T) = %v", tt.v, got)
+		} else if got && bindtype.StreamElem(typ) != reflect.TypeOf(tt.elem) {
+			t.Errorf("StreamElem(%T) = %v, want %T", tt.v, bindtype.StreamElem(typ), tt.elem)
+		}
+	}
+}
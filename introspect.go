@@ -0,0 +1,77 @@
+package webview2
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/logicossoftware/go-webview2/internal/bindtype"
+)
+
+// bindingsMethod is the method called by window.go.__bindings. It is
+// answered with Bindings unless a function is bound under the same name.
+const bindingsMethod = "__bindings"
+
+// BindingInfo describes a bound function as seen from JavaScript.
+type BindingInfo struct {
+	// Name is the name the function is bound under, e.g. "pw.get" for a
+	// method bound with BindObject.
+	Name string `json:"name"`
+
+	// Arity is the number of arguments JavaScript passes, not counting
+	// context.Context and CallInfo parameters. If Variadic is set, the last
+	// one may be repeated or left out.
+	Arity    int  `json:"arity"`
+	Variadic bool `json:"variadic"`
+
+	// Params are the kinds of the parameters, as named by reflect.Kind,
+	// except for "bytes" for binary data and "function" for a JSFunc. The
+	// kind of a variadic parameter is that of its elements.
+	Params []string `json:"params"`
+
+	// Stream is set if the function returns an async iterator.
+	Stream bool `json:"stream"`
+}
+
+func (w *webview) Bindings() []BindingInfo {
+	w.m.Lock()
+	infos := make([]BindingInfo, 0, len(w.bindings))
+	for name, b := range w.bindings {
+		infos = append(infos, bindingInfo(name, reflect.TypeOf(b.f)))
+	}
+	w.m.Unlock()
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+func bindingInfo(name string, t reflect.Type) BindingInfo {
+	info := BindingInfo{Name: name, Variadic: t.IsVariadic(), Params: []string{}}
+	for i := 0; i < t.NumIn(); i++ {
+		in := t.In(i)
+		if isInjected(t, i) {
+			continue
+		}
+		if info.Variadic && i == t.NumIn()-1 {
+			in = in.Elem()
+		}
+		info.Params = append(info.Params, paramKind(in))
+	}
+	info.Arity = len(info.Params)
+	info.Stream = t.NumOut() > 0 && bindtype.IsStream(t.Out(0))
+	return info
+}
+
+func paramKind(t reflect.Type) string {
+	switch {
+	case bindtype.IsBinary(t):
+		return "bytes"
+	case t == reflect.TypeOf(JSFunc{}):
+		return "function"
+	}
+	return t.Kind().String()
+}
+
+// errUnknownBinding returns the error a call of a function that is not
+// bound fails with.
+func errUnknownBinding(method string) error {
+	return &Error{Code: "not_found", Status: 404, Message: "no function is bound as " + method}
+}
@@ -0,0 +1,61 @@
+package webview2
+
+import (
+	"context"
+	"encoding/json"
+	"iter"
+	"reflect"
+	"testing"
+)
+
+type point struct{ X, Y int }
+
+func (p *point) Move(dx, dy int) {}
+
+func TestBindings(t *testing.T) {
+	f := NewFake(WebViewOptions{})
+	for name, fn := range map[string]interface{}{
+		"add":    func(a, b int) int { return a + b },
+		"upload": func(ctx context.Context, info CallInfo, name string, data []byte, progress JSFunc) error { return nil },
+		"join":   func(sep string, parts ...string) string { return "" },
+		"count":  func(n uint) iter.Seq[int] { return nil },
+		"none":   func() {},
+	} {
+		if err := f.Bind(name, fn); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := f.BindObject("shape", &point{}); err != nil {
+		t.Fatal(err)
+	}
+	want := []BindingInfo{
+		{Name: "add", Arity: 2, Params: []string{"int", "int"}},
+		{Name: "count", Arity: 1, Params: []string{"uint"}, Stream: true},
+		{Name: "join", Arity: 2, Variadic: true, Params: []string{"string", "string"}},
+		{Name: "none", Params: []string{}},
+		{Name: "shape.move", Arity: 2, Params: []string{"int", "int"}},
+		{Name: "upload", Arity: 3, Params: []string{"string", "bytes", "function"}},
+	}
+	got := f.Bindings()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Bindings() = %+v, want %+v", got, want)
+	}
+
+	// JavaScript gets the same list.
+	res, err := f.Call(t.Context(), bindingsMethod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := json.Marshal(want)
+	if !sameJSON(res, b) {
+		t.Errorf("%s = %s, want %s", bindingsMethod, res, b)
+	}
+
+	// A function bound under the name takes precedence.
+	if err := f.Bind(bindingsMethod, func() string { return "mine" }); err != nil {
+		t.Fatal(err)
+	}
+	if res, err := f.Call(t.Context(), bindingsMethod); err != nil || string(res) != `"mine"` {
+		t.Errorf("%s = %s, %v, want the bound function's result", bindingsMethod, res, err)
+	}
+}
@@ -0,0 +1,244 @@
+package webview2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"sync"
+
+	"github.com/logicossoftware/go-webview2/internal/bindtype"
+)
+
+// JSFunc is a JavaScript function passed to a bound function, for example a
+// progress callback. Declare a parameter of type JSFunc, or a field,
+// element or pointer of that type within a parameter, to receive one.
+//
+// JavaScript keeps the function alive until Release is called or the page
+// navigates away, after which calls fail.
+type JSFunc struct {
+	w          *webview
+	id         int
+	generation uint64
+}
+
+// UnmarshalJSON decodes the handle JavaScript sends for a function.
+func (f *JSFunc) UnmarshalJSON(data []byte) error {
+	var h *struct {
+		ID int `json:"$func"`
+	}
+	if err := json.Unmarshal(data, &h); err != nil {
+		return err
+	}
+	if h == nil {
+		*f = JSFunc{}
+		return nil
+	}
+	if h.ID <= 0 {
+		return errors.New("argument is not a function")
+	}
+	*f = JSFunc{id: h.ID}
+	return nil
+}
+
+// Call calls the function with args, which are marshaled like the results of
+// bound functions, and returns the eventual result without waiting for it.
+// Calling a nil JSFunc, which JavaScript passes as null or undefined, fails.
+func (f JSFunc) Call(args ...interface{}) *JSResult {
+	r := &JSResult{done: make(chan struct{})}
+	if f.w == nil {
+		r.settle(nil, errors.New("call of nil JavaScript function"))
+		return r
+	}
+
+	f.w.m.Lock()
+	if f.generation != f.w.generation {
+		f.w.m.Unlock()
+		r.settle(nil, errDocumentGone)
+		return r
+	}
+	id := f.w.addCallback(r)
+	f.w.m.Unlock()
+
+	m, err := f.w.message("invoke", append([]interface{}{f.id, id}, args...)...)
+	if err != nil {
+		f.w.settleCallbackResult(id, nil, err)
+		return r
+	}
+	// If the document goes away before the script runs, cancelPending
+	// settles r.
+	f.w.evalIn(f.generation, m)
+	return r
+}
+
+// Release lets JavaScript free the function. It must not be called anymore
+// afterwards.
+func (f JSFunc) Release() {
+	if f.w != nil {
+		f.w.evalIn(f.generation, message{js: "window._rpc.release(" + strconv.Itoa(f.id) + ")"})
+	}
+}
+
+var jsFuncType = reflect.TypeOf(JSFunc{})
+
+// bindFuncs connects the JSFuncs in v, a decoded argument of a call made by
+// the document of generation, to w, so that they can be called. v must be
+// addressable.
+func (w *webview) bindFuncs(v reflect.Value, generation uint64) {
+	if !hasFuncs(v.Type()) {
+		return
+	}
+	switch v.Kind() {
+	case reflect.Pointer:
+		if !v.IsNil() {
+			w.bindFuncs(v.Elem(), generation)
+		}
+	case reflect.Struct:
+		if v.Type() == jsFuncType {
+			if fn := v.Addr().Interface().(*JSFunc); fn.id != 0 {
+				fn.w = w
+				fn.generation = generation
+			}
+			return
+		}
+		for _, f := range cachedFields(v.Type()) {
+			if fv, ok := fieldByIndex(v, f.index); ok {
+				w.bindFuncs(fv, generation)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			w.bindFuncs(v.Index(i), generation)
+		}
+	case reflect.Map:
+		// Map elements are not addressable, so they are connected in a copy.
+		iter := v.MapRange()
+		for iter.Next() {
+			e := reflect.New(v.Type().Elem()).Elem()
+			e.Set(iter.Value())
+			w.bindFuncs(e, generation)
+			v.SetMapIndex(iter.Key(), e)
+		}
+	}
+}
+
+var funcTypes sync.Map // map[reflect.Type]bool
+
+// hasFuncs reports whether values of type t may contain a JSFunc that
+// JavaScript can pass.
+func hasFuncs(t reflect.Type) bool {
+	if has, ok := funcTypes.Load(t); ok {
+		return has.(bool)
+	}
+	has := findFuncs(t, map[reflect.Type]bool{})
+	funcTypes.Store(t, has)
+	return has
+}
+
+func findFuncs(t reflect.Type, visiting map[reflect.Type]bool) bool {
+	if t == jsFuncType {
+		return true
+	}
+	if visiting[t] {
+		return false
+	}
+	visiting[t] = true
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Array, reflect.Map:
+		return findFuncs(t.Elem(), visiting)
+	case reflect.Slice:
+		return !bindtype.IsBinary(t) && findFuncs(t.Elem(), visiting)
+	case reflect.Struct:
+		for _, f := range cachedFields(t) {
+			if findFuncs(t.FieldByIndex(f.index).Type, visiting) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// addCallback registers r to receive the result of a call of JavaScript
+// code. The caller must hold w.m.
+func (w *webview) addCallback(r *JSResult) int {
+	w.nextCallback++
+	w.callbacks[w.nextCallback] = r
+	return w.nextCallback
+}
+
+// settleCallback delivers the result JavaScript posted for callback id.
+func (w *webview) settleCallback(d rpcMessage) {
+	if d.Error != nil {
+		w.settleCallbackResult(d.Callback, nil, d.Error)
+		return
+	}
+	result := d.Result
+	if !w.usesJSON() && len(result) > 0 {
+		// JSResult always holds JSON.
+		var v interface{}
+		err := w.codec.Unmarshal(result, &v)
+		if err == nil {
+			result, err = json.Marshal(v)
+		}
+		if err != nil {
+			w.settleCallbackResult(d.Callback, nil, err)
+			return
+		}
+	}
+	w.settleCallbackResult(d.Callback, result, nil)
+}
+
+func (w *webview) settleCallbackResult(id int, value json.RawMessage, err error) {
+	w.m.Lock()
+	r, ok := w.callbacks[id]
+	delete(w.callbacks, id)
+	w.m.Unlock()
+	if ok {
+		r.settle(value, err)
+	}
+}
+
+// JSResult is the eventual result of calling a JSFunc. If the function
+// returns a promise, it is the settled value of the promise.
+//
+// Results are delivered by the UI thread, so waiting for them on the UI
+// thread blocks forever.
+type JSResult struct {
+	done  chan struct{}
+	value json.RawMessage
+	err   error
+}
+
+func (r *JSResult) settle(value json.RawMessage, err error) {
+	r.value, r.err = value, err
+	close(r.done)
+}
+
+// Done returns a channel that is closed when the result is available.
+func (r *JSResult) Done() <-chan struct{} {
+	return r.done
+}
+
+// Wait waits for the function to return and returns its result as JSON. If
+// it threw an exception, the error is a *JSError.
+func (r *JSResult) Wait(ctx context.Context) (json.RawMessage, error) {
+	select {
+	case <-r.done:
+		return r.value, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Decode waits for the function to return and unmarshals its result into v.
+func (r *JSResult) Decode(ctx context.Context, v interface{}) error {
+	b, err := r.Wait(ctx)
+	if err != nil {
+		return err
+	}
+	if len(b) == 0 {
+		b = []byte("null")
+	}
+	return json.Unmarshal(b, v)
+}
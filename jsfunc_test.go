@@ -0,0 +1,144 @@
+package webview2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+type progressOptions struct {
+	Callback *JSFunc           `json:"callback"`
+	Handlers []JSFunc          `json:"handlers"`
+	Named    map[string]JSFunc `json:"named"`
+}
+
+// funcHandle is what JavaScript passes for the function with handle id.
+func funcHandle(id int) map[string]int { return map[string]int{"$func": id} }
+
+// delivered returns the messages delivered to the document of f by
+// scripts, decoded into the op and its arguments.
+func delivered(f *Fake, scripts []string) [][]interface{} {
+	var msgs [][]interface{}
+	for _, s := range scripts {
+		if msg, ok := f.scriptMessage(s); ok && len(msg) > 0 {
+			msgs = append(msgs, msg)
+		}
+	}
+	return msgs
+}
+
+// invoked returns the callback of the last call of the function with
+// handle id among scripts.
+func invoked(t *testing.T, f *Fake, scripts []string, id int) int {
+	t.Helper()
+	msgs := delivered(f, scripts)
+	for i := len(msgs) - 1; i >= 0; i-- {
+		if msg := msgs[i]; msg[0] == "invoke" && len(msg) >= 3 {
+			if fn, _ := toInt(msg[1]); fn == id {
+				callback, _ := toInt(msg[2])
+				return callback
+			}
+		}
+	}
+	t.Fatalf("function %d was not invoked: %q", id, scripts)
+	return 0
+}
+
+func TestJSFunc(t *testing.T) {
+	for _, codec := range []Codec{JSONCodec, MessagePackCodec, CBORCodec} {
+		t.Run(codec.Name(), func(t *testing.T) {
+			f := NewFake(WebViewOptions{Codec: codec})
+			var scripts []string
+			f.w.browser = scriptBrowser{f.w.browser, &scripts}
+			var fn JSFunc
+			var opts progressOptions
+			if err := f.Bind("start", func(cb JSFunc, o progressOptions) { fn, opts = cb, o }); err != nil {
+				t.Fatal(err)
+			}
+			_, err := f.Call(t.Context(), "start", funcHandle(1), map[string]interface{}{
+				"callback": funcHandle(2),
+				"handlers": []interface{}{funcHandle(3)},
+				"named":    map[string]interface{}{"done": funcHandle(4)},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			funcs := map[int]JSFunc{1: fn, 3: opts.Handlers[0], 4: opts.Named["done"]}
+			if opts.Callback != nil {
+				funcs[2] = *opts.Callback
+			}
+			for id := 1; id <= 4; id++ {
+				fn, ok := funcs[id]
+				if !ok {
+					t.Fatalf("function %d was not decoded", id)
+				}
+				r := fn.Call(id, "x")
+				answer(t, f, invoked(t, f, scripts, id), id*10, nil)
+				var res int
+				if err := r.Decode(t.Context(), &res); err != nil || res != id*10 {
+					t.Errorf("function %d returned %d, %v", id, res, err)
+				}
+			}
+
+			r := fn.Call()
+			answer(t, f, invoked(t, f, scripts, 1), nil, &JSError{Name: "TypeError", Message: "bad"})
+			var jsErr *JSError
+			if _, err := r.Wait(t.Context()); !errors.As(err, &jsErr) || jsErr.Name != "TypeError" || jsErr.Message != "bad" {
+				t.Errorf("throwing function = %v, want its TypeError", err)
+			}
+
+			fn.Release()
+			if msgs := delivered(f, scripts); msgs[len(msgs)-1][0] != "release" {
+				t.Errorf("Release delivered %v", msgs[len(msgs)-1])
+			}
+
+			// Functions of a previous document cannot be called.
+			f.Navigate("https://app.example/")
+			if _, err := fn.Call().Wait(t.Context()); !errors.Is(err, errDocumentGone) {
+				t.Errorf("call after navigation = %v, want %v", err, errDocumentGone)
+			}
+			if _, err := (JSFunc{}).Call().Wait(context.Background()); err == nil {
+				t.Error("call of a nil JSFunc succeeded")
+			}
+		})
+	}
+}
+
+// answer posts the result or error of the call of a JSFunc identified by
+// callback, as JavaScript would.
+func answer(t *testing.T, f *Fake, callback int, result interface{}, err *JSError) {
+	t.Helper()
+	msg, encErr := f.encode(struct {
+		Callback int         `json:"callback"`
+		Result   interface{} `json:"result,omitempty"`
+		Error    *JSError    `json:"error,omitempty"`
+	}{callback, result, err})
+	if encErr != nil {
+		t.Fatal(encErr)
+	}
+	f.Post(msg)
+}
+
+func TestJSFuncArgs(t *testing.T) {
+	f := NewFake(WebViewOptions{})
+	var scripts []string
+	f.w.browser = scriptBrowser{f.w.browser, &scripts}
+	var fn JSFunc
+	if err := f.Bind("start", func(cb JSFunc) { fn = cb }); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Call(t.Context(), "start", funcHandle(7)); err != nil {
+		t.Fatal(err)
+	}
+	fn.Call(1, Bytes{1, 2}, map[string]string{"a": "b"})
+	msgs := delivered(f, scripts)
+	got, err := json.Marshal(msgs[len(msgs)-1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `["invoke",7,1,1,{"$bytes":"AQI="},{"a":"b"}]`; string(got) != want {
+		t.Errorf("delivered %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,189 @@
+package webview2
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// patchOp is an operation of a JSON Patch (RFC 6902). Only add, remove and
+// replace are used.
+type patchOp struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// diffJSON appends to ops the operations that turn a into b. Both are JSON
+// values as decoded by encoding/json into an interface{}.
+func diffJSON(ops []patchOp, path string, a, b interface{}) []patchOp {
+	switch a := a.(type) {
+	case map[string]interface{}:
+		b, ok := b.(map[string]interface{})
+		if !ok {
+			break
+		}
+		for _, k := range sortedKeys(a) {
+			if _, ok := b[k]; !ok {
+				ops = append(ops, patchOp{Op: "remove", Path: path + "/" + escapePointer(k)})
+			}
+		}
+		for _, k := range sortedKeys(b) {
+			if av, ok := a[k]; ok {
+				ops = diffJSON(ops, path+"/"+escapePointer(k), av, b[k])
+			} else {
+				ops = append(ops, patchOp{Op: "add", Path: path + "/" + escapePointer(k), Value: b[k]})
+			}
+		}
+		return ops
+	case []interface{}:
+		b, ok := b.([]interface{})
+		if !ok {
+			break
+		}
+		n := len(a)
+		if len(b) < n {
+			n = len(b)
+		}
+		for i := 0; i < n; i++ {
+			ops = diffJSON(ops, path+"/"+strconv.Itoa(i), a[i], b[i])
+		}
+		for i := len(a) - 1; i >= n; i-- {
+			ops = append(ops, patchOp{Op: "remove", Path: path + "/" + strconv.Itoa(i)})
+		}
+		for i := n; i < len(b); i++ {
+			ops = append(ops, patchOp{Op: "add", Path: path + "/" + strconv.Itoa(i), Value: b[i]})
+		}
+		return ops
+	}
+	if !reflect.DeepEqual(a, b) {
+		ops = append(ops, patchOp{Op: "replace", Path: path, Value: b})
+	}
+	return ops
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func escapePointer(s string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(s)
+}
+
+func unescapePointer(s string) string {
+	return strings.NewReplacer("~1", "/", "~0", "~").Replace(s)
+}
+
+// applyPatch returns doc with ops applied. doc is not modified.
+func applyPatch(doc interface{}, ops []patchOp) (interface{}, error) {
+	doc = copyJSON(doc)
+	for _, op := range ops {
+		var err error
+		if doc, err = applyOp(doc, op); err != nil {
+			return nil, fmt.Errorf("%s %s: %v", op.Op, op.Path, err)
+		}
+	}
+	return doc, nil
+}
+
+func applyOp(doc interface{}, op patchOp) (interface{}, error) {
+	switch op.Op {
+	case "add", "remove", "replace":
+	default:
+		return nil, errors.New("unsupported operation")
+	}
+	if op.Path == "" {
+		if op.Op == "remove" {
+			return nil, nil
+		}
+		return copyJSON(op.Value), nil
+	}
+	if !strings.HasPrefix(op.Path, "/") {
+		return nil, errors.New("invalid path")
+	}
+	tokens := strings.Split(op.Path[1:], "/")
+	parent := doc
+	for _, t := range tokens[:len(tokens)-1] {
+		child, err := childJSON(parent, unescapePointer(t))
+		if err != nil {
+			return nil, err
+		}
+		parent = child
+	}
+	last := unescapePointer(tokens[len(tokens)-1])
+	value := copyJSON(op.Value)
+
+	switch p := parent.(type) {
+	case map[string]interface{}:
+		if _, ok := p[last]; !ok && op.Op != "add" {
+			return nil, errors.New("no such member")
+		}
+		if op.Op == "remove" {
+			delete(p, last)
+		} else {
+			p[last] = value
+		}
+		return doc, nil
+	case []interface{}:
+		// Arrays change length, so they are replaced in their parent.
+		i := len(p)
+		if last != "-" || op.Op != "add" {
+			var err error
+			if i, err = strconv.Atoi(last); err != nil || i < 0 || i > len(p) || i == len(p) && op.Op != "add" {
+				return nil, errors.New("index out of range")
+			}
+		}
+		var arr []interface{}
+		switch op.Op {
+		case "add":
+			arr = append(append(append([]interface{}{}, p[:i]...), value), p[i:]...)
+		case "remove":
+			arr = append(append([]interface{}{}, p[:i]...), p[i+1:]...)
+		case "replace":
+			p[i] = value
+			return doc, nil
+		}
+		return applyOp(doc, patchOp{Op: "replace", Path: op.Path[:strings.LastIndex(op.Path, "/")], Value: arr})
+	}
+	return nil, errors.New("parent is not a container")
+}
+
+func childJSON(v interface{}, token string) (interface{}, error) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if c, ok := v[token]; ok {
+			return c, nil
+		}
+	case []interface{}:
+		if i, err := strconv.Atoi(token); err == nil && i >= 0 && i < len(v) {
+			return v[i], nil
+		}
+	}
+	return nil, errors.New("no such path")
+}
+
+func copyJSON(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[k] = copyJSON(e)
+		}
+		return m
+	case []interface{}:
+		a := make([]interface{}, len(v))
+		for i, e := range v {
+			a[i] = copyJSON(e)
+		}
+		return a
+	}
+	return v
+}
@@ -0,0 +1,91 @@
+package webview2
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestDiffApplyJSON(t *testing.T) {
+	for _, tt := range []struct {
+		a, b string
+		ops  int
+	}{
+		{`1`, `1`, 0},
+		{`1`, `"x"`, 1},
+		{`{"a": 1, "b": 2}`, `{"a": 1, "b": 3}`, 1},
+		{`{"a": 1}`, `{"b": 1}`, 2},
+		{`{"a/b": 1, "c~d": 2}`, `{"a/b": 2}`, 2},
+		{`[1, 2, 3]`, `[1, 2]`, 1},
+		{`[1, 2]`, `[1, 2, 3, 4]`, 2},
+		{`[1, 2, 3]`, `[]`, 3},
+		{`{"l": [{"x": 1}, {"x": 2}]}`, `{"l": [{"x": 1}, {"x": 3, "y": null}]}`, 2},
+		{`{"a": [1]}`, `{"a": {"0": 1}}`, 1},
+		{`null`, `{"a": 1}`, 1},
+	} {
+		var a, b interface{}
+		if err := json.Unmarshal([]byte(tt.a), &a); err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal([]byte(tt.b), &b); err != nil {
+			t.Fatal(err)
+		}
+		ops := diffJSON(nil, "", a, b)
+		if len(ops) != tt.ops {
+			t.Errorf("diff of %s and %s = %+v, want %d operations", tt.a, tt.b, ops, tt.ops)
+		}
+		got, err := applyPatch(a, ops)
+		if err != nil {
+			t.Errorf("applying the diff of %s and %s: %v", tt.a, tt.b, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, b) {
+			t.Errorf("applying the diff of %s and %s gave %v", tt.a, tt.b, got)
+		}
+		var again interface{}
+		_ = json.Unmarshal([]byte(tt.a), &again)
+		if !reflect.DeepEqual(a, again) {
+			t.Errorf("applyPatch modified %s", tt.a)
+		}
+	}
+}
+
+func TestApplyPatch(t *testing.T) {
+	doc := map[string]interface{}{"l": []interface{}{1.0, 2.0}, "m": map[string]interface{}{}}
+	for _, tt := range []struct {
+		ops  string
+		want string // empty if the patch fails
+	}{
+		{`[{"op": "add", "path": "/l/-", "value": 3}]`, `{"l": [1, 2, 3], "m": {}}`},
+		{`[{"op": "add", "path": "/l/0", "value": 0}]`, `{"l": [0, 1, 2], "m": {}}`},
+		{`[{"op": "remove", "path": "/l/1"}]`, `{"l": [1], "m": {}}`},
+		{`[{"op": "replace", "path": "/m", "value": {"k": true}}]`, `{"l": [1, 2], "m": {"k": true}}`},
+		{`[{"op": "add", "path": "/m/a~1b", "value": 1}]`, `{"l": [1, 2], "m": {"a/b": 1}}`},
+		{`[{"op": "replace", "path": "", "value": 5}]`, `5`},
+		{`[{"op": "remove", "path": "/x"}]`, ``},
+		{`[{"op": "replace", "path": "/l/2", "value": 3}]`, ``},
+		{`[{"op": "add", "path": "/l/x", "value": 3}]`, ``},
+		{`[{"op": "add", "path": "/x/y", "value": 3}]`, ``},
+		{`[{"op": "move", "from": "/l", "path": "/k"}]`, ``},
+		{`[{"op": "add", "path": "l", "value": 3}]`, ``},
+	} {
+		var ops []patchOp
+		if err := json.Unmarshal([]byte(tt.ops), &ops); err != nil {
+			t.Fatal(err)
+		}
+		got, err := applyPatch(doc, ops)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("%s applied: %v", tt.ops, got)
+			}
+			continue
+		}
+		var want interface{}
+		if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+			t.Fatal(err)
+		}
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("%s = %v, %v, want %s", tt.ops, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package webview2
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Error codes defined by JSON-RPC 2.0.
+const (
+	jsonrpcParseError     = -32700
+	jsonrpcInvalidRequest = -32600
+	jsonrpcMethodNotFound = -32601
+	jsonrpcInvalidParams  = -32602
+	jsonrpcInternalError  = -32603
+
+	// jsonrpcServerError is used for errors returned by bound functions.
+	jsonrpcServerError = -32000
+)
+
+type jsonrpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+
+	// ID is nil for notifications, which have no id member.
+	ID json.RawMessage `json:"id"`
+}
+
+type jsonrpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *jsonrpcError   `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+type jsonrpcError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// isJSONRPC reports whether msg is meant for the JSON-RPC 2.0 endpoint
+// rather than the JavaScript runtime's own protocol.
+func isJSONRPC(msg string) bool {
+	data := bytes.TrimSpace([]byte(msg))
+	if len(data) == 0 || data[0] != '{' && data[0] != '[' {
+		// Messages of binary codecs are base64-encoded.
+		return false
+	}
+	if data[0] == '[' {
+		return true
+	}
+	var v struct {
+		JSONRPC *string `json:"jsonrpc"`
+	}
+	return json.Unmarshal(data, &v) != nil || v.JSONRPC != nil
+}
+
+// serveJSONRPC handles a JSON-RPC 2.0 request or batch and posts the
+// response to the document that sent it.
+func (w *webview) serveJSONRPC(msg string, source string) {
+	w.m.Lock()
+	generation := w.generation
+	w.m.Unlock()
+
+	data := bytes.TrimSpace([]byte(msg))
+	if !json.Valid(data) {
+		w.metrics.invalidMessage()
+		w.postJSONRPC(generation, newJSONRPCError(nil, jsonrpcParseError, "parse error"))
+		return
+	}
+	if data[0] != '[' {
+		w.serveJSONRPCRequest(data, source, func(resp *jsonrpcResponse) {
+			if resp != nil {
+				w.postJSONRPC(generation, resp)
+			}
+		})
+		return
+	}
+
+	var batch []json.RawMessage
+	if err := json.Unmarshal(data, &batch); err != nil || len(batch) == 0 {
+		w.postJSONRPC(generation, newJSONRPCError(nil, jsonrpcInvalidRequest, "invalid request"))
+		return
+	}
+	responses := make([]*jsonrpcResponse, len(batch))
+	var wg sync.WaitGroup
+	wg.Add(len(batch))
+	for i, req := range batch {
+		w.serveJSONRPCRequest(req, source, func(resp *jsonrpcResponse) {
+			responses[i] = resp
+			wg.Done()
+		})
+	}
+	go func() {
+		wg.Wait()
+		var out []*jsonrpcResponse
+		for _, resp := range responses {
+			if resp != nil {
+				out = append(out, resp)
+			}
+		}
+		if len(out) > 0 {
+			w.postJSONRPC(generation, out)
+		}
+	}()
+}
+
+// serveJSONRPCRequest calls the function requested by data and passes the
+// response to done, or nil for notifications.
+func (w *webview) serveJSONRPCRequest(data json.RawMessage, source string, done func(*jsonrpcResponse)) {
+	var req jsonrpcRequest
+	if err := json.Unmarshal(data, &req); err != nil || req.JSONRPC != "2.0" || req.Method == "" {
+		done(newJSONRPCError(req.ID, jsonrpcInvalidRequest, "invalid request"))
+		return
+	}
+	// A call that timed out is answered before the function returns.
+	var once sync.Once
+	reply := func(resp *jsonrpcResponse) {
+		once.Do(func() {
+			if req.ID == nil {
+				done(nil)
+			} else {
+				done(resp)
+			}
+		})
+	}
+
+	var params []json.RawMessage
+	switch p := bytes.TrimSpace(req.Params); {
+	case len(p) == 0:
+	case p[0] == '[':
+		if err := json.Unmarshal(p, &params); err != nil {
+			reply(newJSONRPCError(req.ID, jsonrpcInvalidParams, err.Error()))
+			return
+		}
+	case p[0] == '{':
+		// Named parameters are passed to the only parameter.
+		params = []json.RawMessage{p}
+	default:
+		reply(newJSONRPCError(req.ID, jsonrpcInvalidRequest, "params must be an array or an object"))
+		return
+	}
+
+	w.m.Lock()
+	_, ok := w.bindings[req.Method]
+	w.m.Unlock()
+	if !ok {
+		reply(newJSONRPCError(req.ID, jsonrpcMethodNotFound, "method not found"))
+		return
+	}
+
+	// JSON-RPC calls are tracked like those of the JavaScript runtime so
+	// they are cancelled on navigation.
+	id := w.nextInternalID()
+	timeout := w.callTimeout(req.Method, 0)
+	ctx, call := w.startCall(req.Method, id, 0, timeout)
+	bc := &BindingCall{
+		CallInfo: w.callInfo(req.Method, source, id),
+		Params:   params,
+		Codec:    JSONCodec,
+	}
+	bc.Context = w.startSpan(ctx, bc.CallInfo, call)
+	respond := func(res interface{}, err error) {
+		start := time.Now()
+		resp := newJSONRPCResponse(req.ID, res, err)
+		call.stats.encoded(time.Since(start))
+		call.stats.finish(err)
+		reply(resp)
+	}
+	w.watchDeadline(ctx, req.Method, timeout, func(err error) {
+		w.finishCall(id, call)
+		respond(nil, err)
+	})
+	if !w.callAllowed(bc.Method, bc.Origin) {
+		err := w.rejectCall(bc.CallInfo)
+		w.finishCall(id, call)
+		respond(nil, err)
+		return
+	}
+	w.executor.execute(req.Method, func() {
+		res, err := w.invoke(bc, call)
+		w.finishCall(id, call)
+		if _, ok := streamValue(res); ok && err == nil {
+			err := errors.New("streaming results are not supported over JSON-RPC")
+			call.stats.finish(err)
+			reply(newJSONRPCError(req.ID, jsonrpcInternalError, err.Error()))
+			return
+		}
+		respond(res, err)
+	})
+}
+
+func newJSONRPCResponse(id json.RawMessage, res interface{}, err error) *jsonrpcResponse {
+	if err != nil {
+		var pe *paramsError
+		if errors.As(err, &pe) {
+			return newJSONRPCError(id, jsonrpcInvalidParams, err.Error())
+		}
+		resp := newJSONRPCError(id, jsonrpcServerError, err.Error())
+		resp.Error.Data = newJSError(err)
+		return resp
+	}
+	b, err := json.Marshal(res)
+	if err != nil {
+		return newJSONRPCError(id, jsonrpcInternalError, err.Error())
+	}
+	return &jsonrpcResponse{JSONRPC: "2.0", Result: b, ID: id}
+}
+
+func newJSONRPCError(id json.RawMessage, code int, message string) *jsonrpcResponse {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	return &jsonrpcResponse{JSONRPC: "2.0", Error: &jsonrpcError{Code: code, Message: message}, ID: id}
+}
+
+// postJSONRPC posts a response to the document identified by generation.
+func (w *webview) postJSONRPC(generation uint64, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		b, _ = json.Marshal(newJSONRPCError(nil, jsonrpcInternalError, err.Error()))
+	}
+	w.recorder.record(TraceEntry{Kind: TracePost, Message: string(b)})
+	w.Dispatch(func() {
+		w.m.Lock()
+		current := generation == w.generation
+		w.m.Unlock()
+		if current {
+			w.browser.PostMessage(string(b))
+		}
+	})
+}
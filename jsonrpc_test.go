@@ -0,0 +1,144 @@
+package webview2
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestJSONRPCWithCodecs(t *testing.T) {
+	for _, codec := range []Codec{JSONCodec, MessagePackCodec, CBORCodec} {
+		t.Run(codec.Name(), func(t *testing.T) {
+			f := NewFake(WebViewOptions{Codec: codec, JSONRPC: true})
+			type point struct {
+				X, Y int
+			}
+			if err := f.Bind("add", func(a, b int) int { return a + b }); err != nil {
+				t.Fatal(err)
+			}
+			if err := f.BindWithOptions("sum", func(p point) int { return p.X + p.Y }, BindOptions{
+				ParamsSchema: json.RawMessage(`{"prefixItems": [{"type": "object", "required": ["X"]}]}`),
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			f.Post(`{"jsonrpc": "2.0", "method": "add", "params": [1, 2], "id": 1}`)
+			f.Post(`{"jsonrpc": "2.0", "method": "sum", "params": {"X": 3, "Y": 4}, "id": 2}`)
+			f.Post(`{"jsonrpc": "2.0", "method": "sum", "params": {"Y": 4}, "id": 3}`)
+
+			posted := f.Posted()
+			if len(posted) != 3 {
+				t.Fatalf("got %d responses, want 3: %q", len(posted), posted)
+			}
+			for i, want := range []string{
+				`{"jsonrpc":"2.0","result":3,"id":1}`,
+				`{"jsonrpc":"2.0","result":7,"id":2}`,
+			} {
+				if posted[i] != want {
+					t.Errorf("response %d = %s, want %s", i, posted[i], want)
+				}
+			}
+			var resp jsonrpcResponse
+			if err := json.Unmarshal([]byte(posted[2]), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Error == nil || resp.Error.Code != jsonrpcInvalidParams {
+				t.Errorf("response to invalid params = %s, want error %d", posted[2], jsonrpcInvalidParams)
+			}
+		})
+	}
+}
+
+// waitPosted waits until n messages were posted to the document of f, as
+// the responses of batches are posted once all their calls returned.
+func waitPosted(t *testing.T, f *Fake, n int) []string {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for len(f.Posted()) < n && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	return f.Posted()
+}
+
+func TestJSONRPCBatch(t *testing.T) {
+	f := NewFake(WebViewOptions{JSONRPC: true})
+	var notified []int
+	if err := f.Bind("add", func(a, b int) int { return a + b }); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Bind("notify", func(n int) { notified = append(notified, n) }); err != nil {
+		t.Fatal(err)
+	}
+
+	f.Post(`[
+		{"jsonrpc": "2.0", "method": "add", "params": [1, 2], "id": "a"},
+		{"jsonrpc": "2.0", "method": "notify", "params": [7]},
+		{"jsonrpc": "2.0", "method": "missing", "id": 2},
+		{"jsonrpc": "1.0", "method": "add", "id": 3},
+		42
+	]`)
+	posts := waitPosted(t, f, 1)
+	want := `[` +
+		`{"jsonrpc":"2.0","result":3,"id":"a"},` +
+		`{"jsonrpc":"2.0","error":{"code":-32601,"message":"method not found"},"id":2},` +
+		`{"jsonrpc":"2.0","error":{"code":-32600,"message":"invalid request"},"id":3},` +
+		`{"jsonrpc":"2.0","error":{"code":-32600,"message":"invalid request"},"id":null}]`
+	if len(posts) != 1 || posts[0] != want {
+		t.Errorf("batch posted %s, want %s", posts, want)
+	}
+	if len(notified) != 1 || notified[0] != 7 {
+		t.Errorf("notification called notify with %v", notified)
+	}
+
+	// A batch of notifications is not answered.
+	f.Post(`[{"jsonrpc": "2.0", "method": "notify", "params": [8]}, {"jsonrpc": "2.0", "method": "notify", "params": [9]}]`)
+	time.Sleep(20 * time.Millisecond)
+	if posts := f.Posted(); len(posts) != 1 || len(notified) != 3 {
+		t.Errorf("batch of notifications posted %q and notified %v", posts[1:], notified)
+	}
+}
+
+func TestJSONRPCErrors(t *testing.T) {
+	f := NewFake(WebViewOptions{JSONRPC: true})
+	if err := f.Bind("fail", func() error {
+		return &Error{Code: "denied", Status: 403, Message: "no"}
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Bind("count", func() <-chan int { return make(chan int) }); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		msg  string
+		code int
+	}{
+		{`{"jsonrpc": "2.0", "method": "fail", "id": 1`, jsonrpcParseError},
+		{`[]`, jsonrpcInvalidRequest},
+		{`{"jsonrpc": "2.0", "id": 1}`, jsonrpcInvalidRequest},
+		{`{"jsonrpc": "2.0", "method": "fail", "params": 1, "id": 1}`, jsonrpcInvalidRequest},
+		{`{"jsonrpc": "2.0", "method": "fail", "params": [1], "id": 1}`, jsonrpcInvalidParams},
+		{`{"jsonrpc": "2.0", "method": "missing", "id": 1}`, jsonrpcMethodNotFound},
+		{`{"jsonrpc": "2.0", "method": "count", "id": 1}`, jsonrpcInternalError},
+		{`{"jsonrpc": "2.0", "method": "fail", "id": 1}`, jsonrpcServerError},
+	} {
+		n := len(f.Posted())
+		f.Post(tt.msg)
+		posted := f.Posted()
+		if len(posted) != n+1 {
+			t.Errorf("%s posted %q, want one response", tt.msg, posted[n:])
+			continue
+		}
+		var resp struct {
+			Error struct {
+				Code int     `json:"code"`
+				Data jsError `json:"data"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal([]byte(posted[n]), &resp); err != nil || resp.Error.Code != tt.code {
+			t.Errorf("%s = %s, want error %d", tt.msg, posted[n], tt.code)
+		}
+		if tt.code == jsonrpcServerError && (resp.Error.Data.Name != "GoError" || resp.Error.Data.Code != "denied" || resp.Error.Data.Status != 403) {
+			t.Errorf("%s = %s, want the GoError as data", tt.msg, posted[n])
+		}
+	}
+}
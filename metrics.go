@@ -0,0 +1,327 @@
+package webview2
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultLatencyBuckets are the upper bounds of histograms unless
+// WebViewOptions.LatencyBuckets sets others.
+var defaultLatencyBuckets = []time.Duration{
+	50 * time.Microsecond,
+	100 * time.Microsecond,
+	250 * time.Microsecond,
+	500 * time.Microsecond,
+	time.Millisecond,
+	2500 * time.Microsecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// Metrics is a snapshot of the metrics of the bridge, as returned by
+// WebView.Metrics.
+type Metrics struct {
+	// Bindings holds the metrics of the functions that were called, sorted
+	// by method. They are kept when a function is unbound.
+	Bindings []BindingMetrics
+
+	// InvalidMessages counts the messages posted to the bridge that could
+	// not be decoded.
+	InvalidMessages uint64
+}
+
+// BindingMetrics are the metrics of the calls of a bound function, made
+// from the JavaScript runtime or with JSON-RPC.
+type BindingMetrics struct {
+	Method string
+
+	// Calls counts the calls, including those in flight.
+	Calls uint64
+
+	// Errors counts the calls that failed, including those that panicked,
+	// timed out or were rejected for their origin.
+	Errors uint64
+
+	// Panics counts the calls in which the function or a middleware
+	// panicked.
+	Panics uint64
+
+	// InFlight is the number of calls not answered yet.
+	InFlight int
+
+	// Latency measures calls from their arrival until their response was
+	// encoded, or the end of the stream for streaming results.
+	Latency Histogram
+
+	// Decode measures decoding and validating the arguments of calls.
+	Decode Histogram
+
+	// Encode measures encoding results, errors and stream items.
+	Encode Histogram
+}
+
+// Histogram counts durations in buckets.
+type Histogram struct {
+	// Buckets are the upper bounds of the buckets, in ascending order.
+	Buckets []time.Duration
+
+	// Counts holds for each bucket the number of durations less than or
+	// equal to its upper bound. Like in Prometheus, the counts are
+	// cumulative.
+	Counts []uint64
+
+	// Count and Sum are the number and the sum of all durations.
+	Count uint64
+	Sum   time.Duration
+}
+
+func newHistogram(buckets []time.Duration) Histogram {
+	return Histogram{Buckets: buckets, Counts: make([]uint64, len(buckets))}
+}
+
+func (h *Histogram) observe(d time.Duration) {
+	h.Count++
+	h.Sum += d
+	// Counts are made cumulative by snapshot.
+	if i := sort.Search(len(h.Buckets), func(i int) bool { return d <= h.Buckets[i] }); i < len(h.Buckets) {
+		h.Counts[i]++
+	}
+}
+
+func (h *Histogram) snapshot() Histogram {
+	s := *h
+	s.Counts = make([]uint64, len(h.Counts))
+	var n uint64
+	for i, c := range h.Counts {
+		n += c
+		s.Counts[i] = n
+	}
+	return s
+}
+
+// metrics collects the metrics of a webview.
+type metrics struct {
+	buckets []time.Duration
+
+	m        sync.Mutex
+	bindings map[string]*BindingMetrics
+	invalid  uint64
+}
+
+func newMetrics(buckets []time.Duration) *metrics {
+	if len(buckets) == 0 {
+		buckets = defaultLatencyBuckets
+	}
+	buckets = append([]time.Duration(nil), buckets...)
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i] < buckets[j] })
+	return &metrics{buckets: buckets, bindings: map[string]*BindingMetrics{}}
+}
+
+// Metrics returns a snapshot of the metrics of the calls of bound
+// functions.
+func (w *webview) Metrics() Metrics {
+	return w.metrics.snapshot()
+}
+
+func (m *metrics) snapshot() Metrics {
+	m.m.Lock()
+	defer m.m.Unlock()
+	s := Metrics{InvalidMessages: m.invalid}
+	for _, b := range m.bindings {
+		c := *b
+		c.Latency = b.Latency.snapshot()
+		c.Decode = b.Decode.snapshot()
+		c.Encode = b.Encode.snapshot()
+		s.Bindings = append(s.Bindings, c)
+	}
+	sort.Slice(s.Bindings, func(i, j int) bool { return s.Bindings[i].Method < s.Bindings[j].Method })
+	return s
+}
+
+func (m *metrics) invalidMessage() {
+	m.m.Lock()
+	m.invalid++
+	m.m.Unlock()
+}
+
+// start counts a call of method.
+func (m *metrics) start(method string) *BindingMetrics {
+	m.m.Lock()
+	defer m.m.Unlock()
+	b, ok := m.bindings[method]
+	if !ok {
+		b = &BindingMetrics{
+			Method:  method,
+			Latency: newHistogram(m.buckets),
+			Decode:  newHistogram(m.buckets),
+			Encode:  newHistogram(m.buckets),
+		}
+		m.bindings[method] = b
+	}
+	b.Calls++
+	b.InFlight++
+	return b
+}
+
+// callStats observes a call for the metrics of its binding and ends its
+// span. Its methods do nothing on a nil callStats.
+type callStats struct {
+	metrics *metrics
+	binding *BindingMetrics // nil for names that are not bound
+	start   time.Time
+
+	endSpan func(err error)
+	once    sync.Once
+}
+
+// startStats starts observing a call of method.
+func (w *webview) startStats(method string) *callStats {
+	s := &callStats{metrics: w.metrics, start: time.Now()}
+	w.m.Lock()
+	_, bound := w.bindings[method]
+	w.m.Unlock()
+	if bound {
+		s.binding = w.metrics.start(method)
+	}
+	return s
+}
+
+// startSpan passes the call described by info to WebViewOptions.StartSpan,
+// if set, and returns the context for the bound function.
+func (w *webview) startSpan(ctx context.Context, info CallInfo, call *pendingCall) context.Context {
+	if w.spanStarter == nil {
+		return ctx
+	}
+	ctx, end := w.spanStarter(ctx, info)
+	call.stats.endSpan = end
+	return ctx
+}
+
+// decoded records the time spent decoding the arguments.
+func (s *callStats) decoded(d time.Duration) {
+	s.observe(func(b *BindingMetrics) { b.Decode.observe(d) })
+}
+
+// encoded records the time spent encoding a result or stream item.
+func (s *callStats) encoded(d time.Duration) {
+	s.observe(func(b *BindingMetrics) { b.Encode.observe(d) })
+}
+
+func (s *callStats) observe(f func(b *BindingMetrics)) {
+	if s == nil || s.binding == nil {
+		return
+	}
+	s.metrics.m.Lock()
+	f(s.binding)
+	s.metrics.m.Unlock()
+}
+
+// finish records the outcome of the call and ends its span. Only the first
+// outcome counts, as a call that timed out is answered again when the
+// function returns.
+func (s *callStats) finish(err error) {
+	if s == nil {
+		return
+	}
+	s.once.Do(func() {
+		s.observe(func(b *BindingMetrics) {
+			b.InFlight--
+			b.Latency.observe(time.Since(s.start))
+			if err != nil {
+				b.Errors++
+			}
+			var p *PanicError
+			if errors.As(err, &p) {
+				b.Panics++
+			}
+		})
+		if s.endSpan != nil {
+			s.endSpan(err)
+		}
+	})
+}
+
+// WritePrometheus writes the metrics to out in the Prometheus text format.
+// The metrics of bindings are labeled with their method.
+func (m Metrics) WritePrometheus(out io.Writer) error {
+	bw := bufio.NewWriter(out)
+	header := func(name, kind, help string) {
+		fmt.Fprintf(bw, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	}
+	counters := []struct {
+		name, kind, help string
+		value            func(b *BindingMetrics) string
+	}{
+		{"webview2_binding_calls_total", "counter", "Calls of bound functions.", func(b *BindingMetrics) string { return strconv.FormatUint(b.Calls, 10) }},
+		{"webview2_binding_errors_total", "counter", "Calls of bound functions that failed.", func(b *BindingMetrics) string { return strconv.FormatUint(b.Errors, 10) }},
+		{"webview2_binding_panics_total", "counter", "Calls of bound functions that panicked.", func(b *BindingMetrics) string { return strconv.FormatUint(b.Panics, 10) }},
+		{"webview2_binding_in_flight", "gauge", "Calls of bound functions not answered yet.", func(b *BindingMetrics) string { return strconv.Itoa(b.InFlight) }},
+	}
+	for _, c := range counters {
+		header(c.name, c.kind, c.help)
+		for i := range m.Bindings {
+			fmt.Fprintf(bw, "%s{method=%s} %s\n", c.name, promLabel(m.Bindings[i].Method), c.value(&m.Bindings[i]))
+		}
+	}
+	histograms := []struct {
+		name, help string
+		value      func(b *BindingMetrics) *Histogram
+	}{
+		{"webview2_binding_duration_seconds", "Latency of calls of bound functions.", func(b *BindingMetrics) *Histogram { return &b.Latency }},
+		{"webview2_binding_decode_seconds", "Time spent decoding the arguments of calls.", func(b *BindingMetrics) *Histogram { return &b.Decode }},
+		{"webview2_binding_encode_seconds", "Time spent encoding the results of calls.", func(b *BindingMetrics) *Histogram { return &b.Encode }},
+	}
+	for _, hist := range histograms {
+		header(hist.name, "histogram", hist.help)
+		for i := range m.Bindings {
+			method := promLabel(m.Bindings[i].Method)
+			h := hist.value(&m.Bindings[i])
+			for j, le := range h.Buckets {
+				fmt.Fprintf(bw, "%s_bucket{method=%s,le=\"%s\"} %d\n", hist.name, method, promSeconds(le), h.Counts[j])
+			}
+			fmt.Fprintf(bw, "%s_bucket{method=%s,le=\"+Inf\"} %d\n", hist.name, method, h.Count)
+			fmt.Fprintf(bw, "%s_sum{method=%s} %s\n", hist.name, method, promSeconds(h.Sum))
+			fmt.Fprintf(bw, "%s_count{method=%s} %d\n", hist.name, method, h.Count)
+		}
+	}
+	header("webview2_invalid_messages_total", "counter", "Messages posted to the bridge that could not be decoded.")
+	fmt.Fprintf(bw, "webview2_invalid_messages_total %d\n", m.InvalidMessages)
+	return bw.Flush()
+}
+
+// MetricsHandler returns a handler serving the metrics of v in the
+// Prometheus text format.
+func MetricsHandler(v WebView) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = v.Metrics().WritePrometheus(rw)
+	})
+}
+
+// promLabel quotes a label value for the Prometheus text format.
+func promLabel(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+// promSeconds formats d in seconds.
+func promSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'g', -1, 64)
+}
@@ -0,0 +1,204 @@
+package webview2
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHistogram(t *testing.T) {
+	h := newHistogram([]time.Duration{time.Millisecond, 10 * time.Millisecond})
+	for _, d := range []time.Duration{time.Millisecond / 2, time.Millisecond, 5 * time.Millisecond, time.Second} {
+		h.observe(d)
+	}
+	s := h.snapshot()
+	if want := []uint64{2, 3}; !reflect.DeepEqual(s.Counts, want) {
+		t.Errorf("Counts = %v, want %v", s.Counts, want)
+	}
+	if s.Count != 4 || s.Sum != time.Second+6500*time.Microsecond {
+		t.Errorf("Count, Sum = %d, %v", s.Count, s.Sum)
+	}
+	// The snapshot does not share the counts.
+	h.observe(0)
+	if s.Counts[0] != 2 {
+		t.Errorf("snapshot changed to %v", s.Counts)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	buckets := []time.Duration{time.Millisecond, time.Hour}
+	f := NewFake(WebViewOptions{JSONRPC: true, LatencyBuckets: buckets})
+	release := make(chan struct{})
+	for name, fn := range map[string]interface{}{
+		"ok":    func(a int) int { return a },
+		"fail":  func() error { return errors.New("boom") },
+		"panic": func() { panic("boom") },
+		"block": func() { <-release },
+	} {
+		if err := f.Bind(name, fn); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f.Call(t.Context(), "ok", 1)
+	f.Call(t.Context(), "ok", 2)
+	if _, err := f.Call(t.Context(), "fail"); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("fail = %v", err)
+	}
+	var p *PanicError
+	if _, err := f.Call(t.Context(), "panic"); !errors.As(err, &p) {
+		t.Errorf("panic = %v", err)
+	}
+	done := make(chan struct{})
+	go func() {
+		f.Call(context.Background(), "block")
+		close(done)
+	}()
+	// Names that are not bound get no metrics, and messages that cannot be
+	// decoded are counted.
+	f.Call(t.Context(), "missing")
+	f.Post(`{"jsonrpc": "2.0", "id": 1, "method": `)
+	f.Post(`not a message`)
+
+	var m Metrics
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+		m = f.Metrics()
+		if len(m.Bindings) == 4 || time.Now().After(deadline) {
+			break
+		}
+	}
+	if m.InvalidMessages != 2 {
+		t.Errorf("InvalidMessages = %d, want 2", m.InvalidMessages)
+	}
+	type counts struct {
+		Method                string
+		Calls, Errors, Panics uint64
+		InFlight              int
+	}
+	var got []counts
+	for _, b := range m.Bindings {
+		got = append(got, counts{b.Method, b.Calls, b.Errors, b.Panics, b.InFlight})
+	}
+	want := []counts{
+		{"block", 1, 0, 0, 1},
+		{"fail", 1, 1, 0, 0},
+		{"ok", 2, 0, 0, 0},
+		{"panic", 1, 1, 1, 0},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("metrics = %+v, want %+v", got, want)
+	}
+	ok := m.Bindings[2]
+	for name, h := range map[string]Histogram{"Latency": ok.Latency, "Decode": ok.Decode, "Encode": ok.Encode} {
+		if !reflect.DeepEqual(h.Buckets, buckets) || h.Count != 2 || h.Counts[1] != 2 {
+			t.Errorf("%s = %+v, want 2 durations in the buckets %v", name, h, buckets)
+		}
+	}
+	if h := m.Bindings[0].Latency; h.Count != 0 {
+		t.Errorf("Latency of a call in flight = %+v", h)
+	}
+
+	close(release)
+	<-done
+	if b := f.Metrics().Bindings[0]; b.InFlight != 0 || b.Latency.Count != 1 {
+		t.Errorf("block = %+v after it returned", b)
+	}
+}
+
+func TestWritePrometheus(t *testing.T) {
+	h := Histogram{
+		Buckets: []time.Duration{time.Millisecond, time.Second},
+		Counts:  []uint64{1, 2},
+		Count:   3,
+		Sum:     2500 * time.Millisecond,
+	}
+	m := Metrics{
+		Bindings: []BindingMetrics{{
+			Method: `a"b`, Calls: 3, Errors: 2, Panics: 1, InFlight: 1,
+			Latency: h, Decode: h, Encode: h,
+		}},
+		InvalidMessages: 4,
+	}
+	var b bytes.Buffer
+	if err := m.WritePrometheus(&b); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, line := range []string{
+		"# TYPE webview2_binding_calls_total counter",
+		`webview2_binding_calls_total{method="a\"b"} 3`,
+		`webview2_binding_errors_total{method="a\"b"} 2`,
+		`webview2_binding_panics_total{method="a\"b"} 1`,
+		"# TYPE webview2_binding_in_flight gauge",
+		`webview2_binding_in_flight{method="a\"b"} 1`,
+		"# TYPE webview2_binding_duration_seconds histogram",
+		`webview2_binding_duration_seconds_bucket{method="a\"b",le="0.001"} 1`,
+		`webview2_binding_duration_seconds_bucket{method="a\"b",le="1"} 2`,
+		`webview2_binding_duration_seconds_bucket{method="a\"b",le="+Inf"} 3`,
+		`webview2_binding_duration_seconds_sum{method="a\"b"} 2.5`,
+		`webview2_binding_duration_seconds_count{method="a\"b"} 3`,
+		`webview2_binding_decode_seconds_count{method="a\"b"} 3`,
+		`webview2_binding_encode_seconds_count{method="a\"b"} 3`,
+		"webview2_invalid_messages_total 4",
+	} {
+		if !strings.Contains(out, line+"\n") {
+			t.Errorf("output lacks %q:\n%s", line, out)
+		}
+	}
+}
+
+func TestMetricsHandler(t *testing.T) {
+	f := NewFake(WebViewOptions{})
+	if err := f.Bind("add", func(a, b int) int { return a + b }); err != nil {
+		t.Fatal(err)
+	}
+	f.Call(t.Context(), "add", 1, 2)
+	rec := httptest.NewRecorder()
+	MetricsHandler(f).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); ct != "text/plain; version=0.0.4; charset=utf-8" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if body := rec.Body.String(); !strings.Contains(body, `webview2_binding_calls_total{method="add"} 1`+"\n") {
+		t.Errorf("body lacks the call of add:\n%s", body)
+	}
+}
+
+type spanKey struct{}
+
+func TestStartSpan(t *testing.T) {
+	var spans []CallInfo
+	var ends []error
+	f := NewFake(WebViewOptions{
+		StartSpan: func(ctx context.Context, info CallInfo) (context.Context, func(err error)) {
+			spans = append(spans, info)
+			return context.WithValue(ctx, spanKey{}, info.Method), func(err error) { ends = append(ends, err) }
+		},
+	})
+	boom := errors.New("boom")
+	var seen []interface{}
+	if err := f.Bind("ok", func(ctx context.Context) { seen = append(seen, ctx.Value(spanKey{})) }); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Bind("fail", func(ctx context.Context) error {
+		seen = append(seen, ctx.Value(spanKey{}))
+		return boom
+	}); err != nil {
+		t.Fatal(err)
+	}
+	f.Call(t.Context(), "ok")
+	f.Call(t.Context(), "fail")
+
+	if len(spans) != 2 || spans[0].Method != "ok" || spans[1].Method != "fail" {
+		t.Fatalf("spans started for %+v", spans)
+	}
+	if want := []interface{}{"ok", "fail"}; !reflect.DeepEqual(seen, want) {
+		t.Errorf("functions got span contexts %v, want %v", seen, want)
+	}
+	if len(ends) != 2 || ends[0] != nil || !errors.Is(ends[1], boom) {
+		t.Errorf("spans ended with %v, want nil and %v", ends, boom)
+	}
+}
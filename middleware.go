@@ -0,0 +1,103 @@
+package webview2
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+)
+
+// CallInfo describes where a call of a bound function comes from. Bound
+// functions may take a CallInfo parameter, which is filled in rather than
+// decoded from the arguments.
+type CallInfo struct {
+	// Method is the name the function is bound under.
+	Method string
+
+	// Source is the URI of the document that made the call.
+	Source string
+
+	// Origin is the origin of Source, e.g. "https://example.com", or
+	// "null" for opaque origins such as those of data URIs.
+	Origin string
+
+	// NavigationID identifies the navigation that loaded the document, as
+	// reported by WebView2's ContentLoading event.
+	NavigationID uint64
+
+	// CallID identifies the call among the calls of the document. Calls made
+	// with JSON-RPC have negative IDs.
+	CallID int
+
+	// TraceID is the trace ID JavaScript sent with the call, e.g. a W3C
+	// traceparent, to correlate it with the spans of the page. It is set
+	// with the trace option of a bound function, or by window.go.trace, and
+	// is empty for calls made with JSON-RPC. See WebViewOptions.StartSpan.
+	TraceID string
+}
+
+var callInfoType = reflect.TypeOf(CallInfo{})
+
+// isInjected reports whether parameter i of the function type t is filled
+// in rather than decoded from the arguments of a call: a leading
+// context.Context, or a CallInfo.
+func isInjected(t reflect.Type, i int) bool {
+	return i == 0 && t.In(0) == contextType || t.In(i) == callInfoType
+}
+
+// callInfo describes call id of method, made by the document at source.
+func (w *webview) callInfo(method, source string, id int) CallInfo {
+	w.m.Lock()
+	navigationID := w.navigationID
+	w.m.Unlock()
+	return CallInfo{
+		Method:       method,
+		Source:       source,
+		Origin:       originOf(source),
+		NavigationID: navigationID,
+		CallID:       id,
+	}
+}
+
+// BindingCall is a call of a bound function as seen by middleware.
+type BindingCall struct {
+	CallInfo
+
+	// Context is the context passed to functions that take one. It is
+	// cancelled when the call is aborted.
+	Context context.Context
+
+	// Params are the encoded arguments of the call, which Codec decodes.
+	Params []json.RawMessage
+
+	// Codec is the codec the call was encoded with: WebViewOptions.Codec for
+	// calls of the JavaScript runtime, and JSONCodec for JSON-RPC requests.
+	Codec Codec
+}
+
+// BindingHandler handles a call of a bound function and returns its result.
+type BindingHandler func(call *BindingCall) (interface{}, error)
+
+// BindingMiddleware wraps the handling of calls of bound functions. It may
+// inspect or change the call before passing it to next, return without
+// calling next, and inspect or replace the result.
+type BindingMiddleware func(next BindingHandler) BindingHandler
+
+// Use adds middleware around the calls of all bound functions. The
+// middleware added first is the outermost. Use affects calls made after it
+// returns.
+func (w *webview) Use(mw ...BindingMiddleware) {
+	w.m.Lock()
+	w.middleware = append(w.middleware[:len(w.middleware):len(w.middleware)], mw...)
+	w.m.Unlock()
+}
+
+// handler returns the middleware chain around h.
+func (w *webview) handler(h BindingHandler) BindingHandler {
+	w.m.Lock()
+	mw := w.middleware
+	w.m.Unlock()
+	for i := len(mw) - 1; i >= 0; i-- {
+		h = mw[i](h)
+	}
+	return h
+}
@@ -0,0 +1,127 @@
+package webview2
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestUse(t *testing.T) {
+	f := NewFake(WebViewOptions{})
+	var order []string
+	trace := func(name string) BindingMiddleware {
+		return func(next BindingHandler) BindingHandler {
+			return func(call *BindingCall) (interface{}, error) {
+				order = append(order, name+" "+call.Method)
+				res, err := next(call)
+				order = append(order, name+" done")
+				return res, err
+			}
+		}
+	}
+	calls := 0
+	if err := f.Bind("add", func(a, b int) int {
+		calls++
+		return a + b
+	}); err != nil {
+		t.Fatal(err)
+	}
+	f.Use(trace("outer"), trace("middle"))
+	f.Use(trace("inner"))
+	if res, err := f.Call(t.Context(), "add", 1, 2); err != nil || string(res) != "3" {
+		t.Fatalf("add = %s, %v", res, err)
+	}
+	want := []string{"outer add", "middle add", "inner add", "inner done", "middle done", "outer done"}
+	if len(order) != len(want) {
+		t.Fatalf("middleware ran as %q, want %q", order, want)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("middleware ran as %q, want %q", order, want)
+		}
+	}
+
+	// Middleware may answer without calling the function, or replace its
+	// result.
+	denied := &Error{Code: "denied", Message: "no"}
+	f.Use(func(next BindingHandler) BindingHandler {
+		return func(call *BindingCall) (interface{}, error) {
+			if call.Method == "add" {
+				return nil, denied
+			}
+			return next(call)
+		}
+	})
+	order = nil
+	_, err := f.Call(t.Context(), "add", 1, 2)
+	var e *Error
+	if !errors.As(err, &e) || e.Code != "denied" || calls != 1 {
+		t.Errorf("add = %v after %d calls, want it denied without calling it", err, calls)
+	}
+	if len(order) != 6 {
+		t.Errorf("outer middleware ran as %q around the short circuit", order)
+	}
+}
+
+func TestUseReplacesResult(t *testing.T) {
+	f := NewFake(WebViewOptions{})
+	if err := f.Bind("name", func() string { return "ada" }); err != nil {
+		t.Fatal(err)
+	}
+	f.Use(func(next BindingHandler) BindingHandler {
+		return func(call *BindingCall) (interface{}, error) {
+			res, err := next(call)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{"result": res}, nil
+		}
+	})
+	if res, err := f.Call(t.Context(), "name"); err != nil || string(res) != `{"result":"ada"}` {
+		t.Errorf("name = %s, %v", res, err)
+	}
+}
+
+func TestCallInfo(t *testing.T) {
+	f := NewFake(WebViewOptions{JSONRPC: true})
+	f.Navigate("https://app.example/page?x=1")
+	var got []CallInfo
+	for name, fn := range map[string]interface{}{
+		"first":    func(info CallInfo, a, b int) int { got = append(got, info); return a - b },
+		"middle":   func(a int, info CallInfo, b int) int { got = append(got, info); return a - b },
+		"last":     func(a, b int, info CallInfo) int { got = append(got, info); return a - b },
+		"context":  func(ctx context.Context, info CallInfo, a, b int) int { got = append(got, info); return a - b },
+		"variadic": func(info CallInfo, a int, rest ...int) int { got = append(got, info); return a - rest[0] },
+	} {
+		if err := f.Bind(name, fn); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"first", "middle", "last", "context", "variadic"} {
+		got = nil
+		res, err := f.Call(t.Context(), name, 3, 1)
+		if err != nil || string(res) != "2" {
+			t.Errorf("%s = %s, %v, want 2", name, res, err)
+			continue
+		}
+		if info := got[0]; info.Method != name || info.Source != "https://app.example/page?x=1" ||
+			info.Origin != "https://app.example" || info.NavigationID != 1 || info.CallID >= 0 {
+			t.Errorf("%s got %+v", name, info)
+		}
+		if b := bindingInfo(name, reflect.TypeOf(f.w.bindings[name].f)); b.Arity != 2 {
+			t.Errorf("%s has arity %d, want 2 without CallInfo", name, b.Arity)
+		}
+	}
+
+	// JavaScript cannot pass a CallInfo in its place.
+	got = nil
+	f.Post(`{"id": 1, "method": "first", "params": [{"Method": "forged"}, 3, 1]}`)
+	if got != nil {
+		t.Errorf("call with an extra argument got %+v", got)
+	}
+	f.Post(`{"id": 2, "method": "first", "params": [3, 1]}`)
+	if len(got) != 1 || got[0].CallID != 2 {
+		t.Errorf("call 2 got %+v", got)
+	}
+}
@@ -0,0 +1,248 @@
+package webview2
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// msgpackFormat implements the MessagePack format. Extension types are not
+// supported.
+type msgpackFormat struct{}
+
+func (msgpackFormat) appendNil(b []byte) []byte { return append(b, 0xc0) }
+
+func (msgpackFormat) appendBool(b []byte, v bool) []byte {
+	if v {
+		return append(b, 0xc3)
+	}
+	return append(b, 0xc2)
+}
+
+func (f msgpackFormat) appendInt(b []byte, v int64) []byte {
+	switch {
+	case v >= 0:
+		return f.appendUint(b, uint64(v))
+	case v >= -32:
+		return append(b, byte(v))
+	case v >= math.MinInt8:
+		return append(b, 0xd0, byte(v))
+	case v >= math.MinInt16:
+		return binary.BigEndian.AppendUint16(append(b, 0xd1), uint16(v))
+	case v >= math.MinInt32:
+		return binary.BigEndian.AppendUint32(append(b, 0xd2), uint32(v))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, 0xd3), uint64(v))
+	}
+}
+
+func (msgpackFormat) appendUint(b []byte, v uint64) []byte {
+	switch {
+	case v < 0x80:
+		return append(b, byte(v))
+	case v <= math.MaxUint8:
+		return append(b, 0xcc, byte(v))
+	case v <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, 0xcd), uint16(v))
+	case v <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(b, 0xce), uint32(v))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, 0xcf), v)
+	}
+}
+
+func (msgpackFormat) appendFloat(b []byte, v float64, bits int) []byte {
+	if bits == 32 {
+		return binary.BigEndian.AppendUint32(append(b, 0xca), math.Float32bits(float32(v)))
+	}
+	return binary.BigEndian.AppendUint64(append(b, 0xcb), math.Float64bits(v))
+}
+
+func (msgpackFormat) appendString(b []byte, v string) []byte {
+	switch n := len(v); {
+	case n < 32:
+		b = append(b, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		b = append(b, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		b = binary.BigEndian.AppendUint16(append(b, 0xda), uint16(n))
+	default:
+		b = binary.BigEndian.AppendUint32(append(b, 0xdb), uint32(n))
+	}
+	return append(b, v...)
+}
+
+func (msgpackFormat) appendBytes(b []byte, v []byte) []byte {
+	switch n := len(v); {
+	case n <= math.MaxUint8:
+		b = append(b, 0xc4, byte(n))
+	case n <= math.MaxUint16:
+		b = binary.BigEndian.AppendUint16(append(b, 0xc5), uint16(n))
+	default:
+		b = binary.BigEndian.AppendUint32(append(b, 0xc6), uint32(n))
+	}
+	return append(b, v...)
+}
+
+func (msgpackFormat) appendArrayHeader(b []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(b, 0x90|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, 0xdc), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(b, 0xdd), uint32(n))
+	}
+}
+
+func (msgpackFormat) appendMapHeader(b []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(b, 0x80|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, 0xde), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(b, 0xdf), uint32(n))
+	}
+}
+
+func (f msgpackFormat) read(b []byte) (interface{}, []byte, error) {
+	if len(b) == 0 {
+		return nil, nil, errTruncated
+	}
+	t, b := b[0], b[1:]
+	switch {
+	case t < 0x80:
+		return int64(t), b, nil
+	case t >= 0xe0:
+		return int64(int8(t)), b, nil
+	case t&0xf0 == 0x80:
+		return readMap(f, b, int(t&0x0f))
+	case t&0xf0 == 0x90:
+		return readArray(f, b, int(t&0x0f))
+	case t&0xe0 == 0xa0:
+		return readString(b, int(t&0x1f))
+	}
+
+	// size reads a big-endian length or value of n bytes.
+	size := func(n int) (uint64, error) {
+		if len(b) < n {
+			return 0, errTruncated
+		}
+		var v uint64
+		for _, c := range b[:n] {
+			v = v<<8 | uint64(c)
+		}
+		b = b[n:]
+		return v, nil
+	}
+	switch t {
+	case 0xc0:
+		return nil, b, nil
+	case 0xc2:
+		return false, b, nil
+	case 0xc3:
+		return true, b, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := size(1 << (t - 0xc4))
+		if err != nil {
+			return nil, nil, err
+		}
+		s, rest, err := readString(b, int(n))
+		if err != nil {
+			return nil, nil, err
+		}
+		return []byte(s.(string)), rest, nil
+	case 0xca:
+		v, err := size(4)
+		return float64(math.Float32frombits(uint32(v))), b, err
+	case 0xcb:
+		v, err := size(8)
+		return math.Float64frombits(v), b, err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		v, err := size(1 << (t - 0xcc))
+		if v <= math.MaxInt64 {
+			return int64(v), b, err
+		}
+		return v, b, err
+	case 0xd0:
+		v, err := size(1)
+		return int64(int8(v)), b, err
+	case 0xd1:
+		v, err := size(2)
+		return int64(int16(v)), b, err
+	case 0xd2:
+		v, err := size(4)
+		return int64(int32(v)), b, err
+	case 0xd3:
+		v, err := size(8)
+		return int64(v), b, err
+	case 0xd9, 0xda, 0xdb:
+		n, err := size(1 << (t - 0xd9))
+		if err != nil {
+			return nil, nil, err
+		}
+		return readString(b, int(n))
+	case 0xdc, 0xdd:
+		n, err := size(2 << (t - 0xdc))
+		if err != nil {
+			return nil, nil, err
+		}
+		return readArray(f, b, int(n))
+	case 0xde, 0xdf:
+		n, err := size(2 << (t - 0xde))
+		if err != nil {
+			return nil, nil, err
+		}
+		return readMap(f, b, int(n))
+	}
+	return nil, nil, fmt.Errorf("msgpack: unsupported type 0x%02x", t)
+}
+
+// readString reads a string of n bytes.
+func readString(b []byte, n int) (interface{}, []byte, error) {
+	if n < 0 || len(b) < n {
+		return nil, nil, errTruncated
+	}
+	return string(b[:n]), b[n:], nil
+}
+
+func readArray(f format, b []byte, n int) (interface{}, []byte, error) {
+	// Every item takes at least one byte, which bounds the allocation.
+	if n < 0 || n > len(b) {
+		return nil, nil, errTruncated
+	}
+	items := make([]interface{}, n)
+	for i := range items {
+		var err error
+		if items[i], b, err = f.read(b); err != nil {
+			return nil, nil, err
+		}
+	}
+	return items, b, nil
+}
+
+func readMap(f format, b []byte, n int) (interface{}, []byte, error) {
+	if n < 0 || n > len(b) {
+		return nil, nil, errTruncated
+	}
+	m := make(map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		k, rest, err := f.read(b)
+		if err != nil {
+			return nil, nil, err
+		}
+		key, ok := k.(string)
+		if !ok {
+			if k == nil {
+				return nil, nil, errors.New("map key is nil")
+			}
+			key = fmt.Sprint(k)
+		}
+		if m[key], b, err = f.read(rest); err != nil {
+			return nil, nil, err
+		}
+	}
+	return m, b, nil
+}
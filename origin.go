@@ -0,0 +1,84 @@
+package webview2
+
+import (
+	"log"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// originOf returns the origin of the document at uri as serialized by
+// browsers.
+func originOf(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Host == "" || u.Scheme != "http" && u.Scheme != "https" {
+		return "null"
+	}
+	host := strings.ToLower(u.Hostname())
+	switch port := u.Port(); {
+	case port != "" && !(u.Scheme == "http" && port == "80" || u.Scheme == "https" && port == "443"):
+		host = net.JoinHostPort(host, port)
+	case strings.Contains(host, ":"):
+		host = "[" + host + "]"
+	}
+	return u.Scheme + "://" + host
+}
+
+// matchOrigin reports whether origin is matched by pattern, which is an
+// origin, an origin whose host starts with "*." to match any subdomain, or
+// "*" to match every origin.
+func matchOrigin(pattern, origin string) bool {
+	pattern = strings.ToLower(strings.TrimSuffix(pattern, "/"))
+	if pattern == "*" || pattern == origin {
+		return true
+	}
+	scheme, host, ok := strings.Cut(pattern, "://*.")
+	if !ok {
+		return false
+	}
+	rest, ok := strings.CutPrefix(origin, scheme+"://")
+	return ok && strings.HasSuffix(rest, "."+host)
+}
+
+func matchOrigins(patterns []string, origin string) bool {
+	for _, p := range patterns {
+		if matchOrigin(p, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// callAllowed reports whether documents of origin may call method. The
+// origins of the binding take precedence over the global ones.
+func (w *webview) callAllowed(method, origin string) bool {
+	w.m.Lock()
+	patterns := w.allowedOrigins
+	if b, ok := w.bindings[method]; ok && len(b.origins) > 0 {
+		patterns = b.origins
+	}
+	w.m.Unlock()
+	return len(patterns) == 0 || matchOrigins(patterns, origin)
+}
+
+// eventAllowed reports whether documents of origin may emit events.
+func (w *webview) eventAllowed(topic, origin string) bool {
+	if len(w.allowedOrigins) == 0 || matchOrigins(w.allowedOrigins, origin) {
+		return true
+	}
+	log.Printf("dropped event %s from disallowed origin %s", topic, origin)
+	return false
+}
+
+// rejectCall reports a call from a disallowed origin and returns the error
+// its promise is rejected with.
+func (w *webview) rejectCall(info CallInfo) error {
+	if w.callRejected != nil {
+		w.callRejected(info)
+	}
+	return &Error{
+		Code:    "forbidden",
+		Status:  403,
+		Message: "documents from " + info.Origin + " may not call " + info.Method,
+	}
+}
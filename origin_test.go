@@ -0,0 +1,83 @@
+package webview2
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestOriginOf(t *testing.T) {
+	for uri, want := range map[string]string{
+		"https://app.example/path?q#f":  "https://app.example",
+		"HTTPS://App.Example:443/":      "https://app.example",
+		"http://app.example:80/":        "http://app.example",
+		"http://app.example:8080/x":     "http://app.example:8080",
+		"https://[::1]/":                "https://[::1]",
+		"https://[::1]:8443/":           "https://[::1]:8443",
+		"https://user:pw@app.example/":  "https://app.example",
+		"about:blank":                   "null",
+		"data:text/html,<p>hi</p>":      "null",
+		"file:///C:/index.html":         "null",
+		"https://go-webview2.localhost": "https://go-webview2.localhost",
+		"%":                             "null",
+	} {
+		if got := originOf(uri); got != want {
+			t.Errorf("originOf(%q) = %q, want %q", uri, got, want)
+		}
+	}
+}
+
+func TestMatchOrigin(t *testing.T) {
+	for _, tt := range []struct {
+		pattern, origin string
+		want            bool
+	}{
+		{"https://app.example", "https://app.example", true},
+		{"https://app.example/", "https://app.example", true},
+		{"HTTPS://APP.example", "https://app.example", true},
+		{"https://app.example", "http://app.example", false},
+		{"https://app.example", "https://app.example:8443", false},
+		{"https://*.example", "https://a.b.example", true},
+		{"https://*.example", "https://example", false},
+		{"https://*.example", "https://badexample", false},
+		{"https://*.example", "http://a.example", false},
+		{"null", "null", true},
+		{"*", "null", true},
+	} {
+		if got := matchOrigin(tt.pattern, tt.origin); got != tt.want {
+			t.Errorf("matchOrigin(%q, %q) = %v", tt.pattern, tt.origin, got)
+		}
+	}
+}
+
+func TestAllowedOrigins(t *testing.T) {
+	var rejected []CallInfo
+	f := NewFake(WebViewOptions{
+		AllowedOrigins:       []string{"https://app.example"},
+		CallRejectedCallback: func(info CallInfo) { rejected = append(rejected, info) },
+	})
+	if err := f.Bind("ping", func() string { return "pong" }); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.BindWithOptions("open", func() string { return "open" }, BindOptions{AllowedOrigins: []string{"*"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	f.Navigate("https://app.example/index.html")
+	if _, err := f.Call(context.Background(), "ping"); err != nil {
+		t.Errorf("call from an allowed origin: %v", err)
+	}
+
+	f.Navigate("https://evil.example/")
+	_, err := f.Call(context.Background(), "ping")
+	var e *Error
+	if !errors.As(err, &e) || e.Status != 403 {
+		t.Errorf("call from another origin = %v, want a 403 error", err)
+	}
+	if len(rejected) != 1 || rejected[0].Origin != "https://evil.example" {
+		t.Errorf("rejected calls %+v", rejected)
+	}
+	if _, err := f.Call(context.Background(), "open"); err != nil {
+		t.Errorf("call of a binding open to all origins: %v", err)
+	}
+}
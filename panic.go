@@ -0,0 +1,60 @@
+package webview2
+
+import (
+	"fmt"
+	"log"
+	"runtime/debug"
+)
+
+// PanicError is the error a call of a bound function fails with when the
+// function or a middleware panicked. Its promise is rejected with a
+// window.go.GoPanic, a GoError with code "panic". The stack is not sent to
+// JavaScript.
+type PanicError struct {
+	CallInfo
+
+	// Value is the value passed to panic.
+	Value interface{}
+
+	// Stack is the stack trace of the goroutine that panicked, as formatted
+	// by runtime/debug.Stack.
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic in %s: %v", e.Method, e.Value)
+}
+
+func (e *PanicError) ErrorCode() string { return "panic" }
+
+// Unwrap returns the value passed to panic if it is an error, such as a
+// runtime.Error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// recoverPanic must be deferred. It turns a panic into a PanicError stored
+// in *err and reports it.
+func (w *webview) recoverPanic(info CallInfo, err *error) {
+	v := recover()
+	if v == nil {
+		return
+	}
+	p := &PanicError{CallInfo: info, Value: v, Stack: debug.Stack()}
+	*err = p
+	if w.bindingPanic != nil {
+		w.bindingPanic(p)
+	} else {
+		log.Printf("%v\n%s", p, p.Stack)
+	}
+}
+
+// invoke calls the bound function through the middleware. Panics are
+// recovered and returned as a PanicError.
+func (w *webview) invoke(bc *BindingCall, call *pendingCall) (res interface{}, err error) {
+	defer w.recoverPanic(bc.CallInfo, &err)
+	return w.handler(func(bc *BindingCall) (interface{}, error) {
+		return w.callbinding(bc, call)
+	})(bc)
+}
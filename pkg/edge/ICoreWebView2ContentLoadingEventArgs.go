@@ -0,0 +1,46 @@
+package edge
+
+import (
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+type _ICoreWebView2ContentLoadingEventArgsVtbl struct {
+	_IUnknownVtbl
+	GetIsErrorPage  ComProc
+	GetNavigationId ComProc
+}
+
+type ICoreWebView2ContentLoadingEventArgs struct {
+	vtbl *_ICoreWebView2ContentLoadingEventArgsVtbl
+}
+
+func (i *ICoreWebView2ContentLoadingEventArgs) AddRef() uintptr {
+	r, _, _ := i.vtbl.AddRef.Call(uintptr(unsafe.Pointer(i)))
+	return r
+}
+
+func (i *ICoreWebView2ContentLoadingEventArgs) GetIsErrorPage() (bool, error) {
+	var isErrorPage bool
+	_, _, err := i.vtbl.GetIsErrorPage.Call(
+		uintptr(unsafe.Pointer(i)),
+		uintptr(unsafe.Pointer(&isErrorPage)),
+	)
+	if err != windows.ERROR_SUCCESS {
+		return false, err
+	}
+	return isErrorPage, nil
+}
+
+func (i *ICoreWebView2ContentLoadingEventArgs) GetNavigationID() (uint64, error) {
+	var id uint64
+	_, _, err := i.vtbl.GetNavigationId.Call(
+		uintptr(unsafe.Pointer(i)),
+		uintptr(unsafe.Pointer(&id)),
+	)
+	if err != windows.ERROR_SUCCESS {
+		return 0, err
+	}
+	return id, nil
+}
@@ -26,6 +26,11 @@ func (i *ICoreWebView2WebResourceResponse) AddRef() uintptr {
 	return r
 }
 
+func (i *ICoreWebView2WebResourceResponse) Release() uintptr {
+	r, _, _ := i.vtbl.Release.Call(uintptr(unsafe.Pointer(i)))
+	return r
+}
+
 // GetContent returns the underlying IStream* for the response body.
 func (i *ICoreWebView2WebResourceResponse) GetContent() (uintptr, error) {
 	var stream uintptr
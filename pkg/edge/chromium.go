@@ -27,10 +27,16 @@ type Chromium struct {
 	webResourceRequested  *iCoreWebView2WebResourceRequestedEventHandler
 	acceleratorKeyPressed *ICoreWebView2AcceleratorKeyPressedEventHandler
 	navigationCompleted   *ICoreWebView2NavigationCompletedEventHandler
+	contentLoading        *ICoreWebView2ContentLoadingEventHandler
 	downloadStarting      *iCoreWebView2DownloadStartingEventHandler
 
 	environment *ICoreWebView2Environment
 
+	// initScripts and evals keep completion handlers of AddInitScript and
+	// EvalWithResult alive until WebView2 invoked them.
+	initScripts map[*initScriptHandler]struct{}
+	evals       map[*evalHandler]struct{}
+
 	// Settings
 	DataPath string
 
@@ -40,8 +46,10 @@ type Chromium struct {
 
 	// Callbacks
 	MessageCallback              func(string)
+	WebMessageCallback           func(message string, source string)
 	WebResourceRequestedCallback func(request *ICoreWebView2WebResourceRequest, args *ICoreWebView2WebResourceRequestedEventArgs)
 	NavigationCompletedCallback  func(sender *ICoreWebView2, args *ICoreWebView2NavigationCompletedEventArgs)
+	ContentLoadingCallback       func(sender *ICoreWebView2, args *ICoreWebView2ContentLoadingEventArgs)
 	AcceleratorKeyCallback       func(uint) bool
 	DownloadStartingCallback     func(sender *ICoreWebView2, args *ICoreWebView2DownloadStartingEventArgs)
 }
@@ -66,8 +74,11 @@ func NewChromium() *Chromium {
 	e.webResourceRequested = newICoreWebView2WebResourceRequestedEventHandler(e)
 	e.acceleratorKeyPressed = newICoreWebView2AcceleratorKeyPressedEventHandler(e)
 	e.navigationCompleted = newICoreWebView2NavigationCompletedEventHandler(e)
+	e.contentLoading = newICoreWebView2ContentLoadingEventHandler(e)
 	e.downloadStarting = newICoreWebView2DownloadStartingEventHandler(e)
 	e.permissions = make(map[CoreWebView2PermissionKind]CoreWebView2PermissionState)
+	e.initScripts = make(map[*initScriptHandler]struct{})
+	e.evals = make(map[*evalHandler]struct{})
 
 	return e
 }
@@ -134,12 +145,115 @@ func (e *Chromium) Init(script string) {
 	}
 }
 
+// AddInitScript is like Init, but calls done with the ID WebView2 assigned to
+// the script once it has been added. The ID can be passed to
+// RemoveInitScript.
+func (e *Chromium) AddInitScript(script string, done func(id string, err error)) {
+	h := &initScriptHandler{e: e, done: done}
+	h.handler = newICoreWebView2AddScriptToExecuteOnDocumentCreatedCompletedHandler(h)
+	e.initScripts[h] = struct{}{}
+	if err := e.webview.AddScriptToExecuteOnDocumentCreatedWithHandler(script, h.handler); err != nil {
+		delete(e.initScripts, h)
+		done("", err)
+	}
+}
+
+// RemoveInitScript removes a script added with AddInitScript from future
+// documents.
+func (e *Chromium) RemoveInitScript(id string) error {
+	return e.webview.RemoveScriptToExecuteOnDocumentCreated(id)
+}
+
 func (e *Chromium) Eval(script string) {
 	if err := e.webview.ExecuteScript(script); err != nil {
 		log.Printf("WebView2 ExecuteScript failed: %v", err)
 	}
 }
 
+// PostMessage posts message to the current document as a string, which it
+// receives as the data of a message event of window.chrome.webview.
+func (e *Chromium) PostMessage(message string) {
+	if err := e.webview.PostWebMessageAsString(message); err != nil {
+		log.Printf("WebView2 PostWebMessageAsString failed: %v", err)
+	}
+}
+
+// PostMessageJSON posts json to the current document, which receives the
+// value it encodes as the data of a message event of window.chrome.webview.
+func (e *Chromium) PostMessageJSON(json string) error {
+	return e.webview.PostWebMessageAsJSON(json)
+}
+
+// EvalWithResult is like Eval, but calls done with the result of the script
+// serialized as JSON. The result is "null" if the script threw an exception.
+func (e *Chromium) EvalWithResult(script string, done func(result string, err error)) {
+	h := &evalHandler{e: e, done: done}
+	h.handler = newICoreWebView2ExecuteScriptCompletedHandler(h)
+	e.evals[h] = struct{}{}
+	if err := e.webview.ExecuteScriptWithHandler(script, h.handler); err != nil {
+		delete(e.evals, h)
+		done("", err)
+	}
+}
+
+// initScriptHandler receives the result of AddInitScript.
+type initScriptHandler struct {
+	e       *Chromium
+	done    func(id string, err error)
+	handler *ICoreWebView2AddScriptToExecuteOnDocumentCreatedCompletedHandler
+}
+
+func (h *initScriptHandler) QueryInterface(_, _ uintptr) uintptr {
+	return 0
+}
+
+func (h *initScriptHandler) AddRef() uintptr {
+	return 1
+}
+
+func (h *initScriptHandler) Release() uintptr {
+	return 1
+}
+
+func (h *initScriptHandler) AddScriptToExecuteOnDocumentCreatedCompleted(errorCode uintptr, id string) uintptr {
+	delete(h.e.initScripts, h)
+	if int32(errorCode) < 0 {
+		h.done("", windows.Errno(errorCode))
+	} else {
+		h.done(id, nil)
+	}
+	return 0
+}
+
+// evalHandler receives the result of EvalWithResult.
+type evalHandler struct {
+	e       *Chromium
+	done    func(result string, err error)
+	handler *ICoreWebView2ExecuteScriptCompletedHandler
+}
+
+func (h *evalHandler) QueryInterface(_, _ uintptr) uintptr {
+	return 0
+}
+
+func (h *evalHandler) AddRef() uintptr {
+	return 1
+}
+
+func (h *evalHandler) Release() uintptr {
+	return 1
+}
+
+func (h *evalHandler) ExecuteScriptCompleted(errorCode uintptr, resultObjectAsJson string) uintptr {
+	delete(h.e.evals, h)
+	if int32(errorCode) < 0 {
+		h.done("", windows.Errno(errorCode))
+	} else {
+		h.done(resultObjectAsJson, nil)
+	}
+	return 0
+}
+
 func (e *Chromium) Show() error {
 	return e.controller.PutIsVisible(true)
 }
@@ -202,6 +316,9 @@ func (e *Chromium) CreateCoreWebView2ControllerCompleted(res uintptr, controller
 	if err := e.webview.AddNavigationCompleted(e.navigationCompleted, &token); err != nil {
 		log.Printf("WebView2 AddNavigationCompleted failed: %v", err)
 	}
+	if err := e.webview.AddContentLoadingRaw(uintptr(unsafe.Pointer(e.contentLoading)), &token); err != nil {
+		log.Printf("WebView2 AddContentLoading failed: %v", err)
+	}
 	if wv4 := e.webview.GetICoreWebView2_4(); wv4 != nil {
 		if err := wv4.AddDownloadStartingRaw(uintptr(unsafe.Pointer(e.downloadStarting)), &token); err != nil {
 			log.Printf("WebView2 AddDownloadStarting failed: %v", err)
@@ -225,10 +342,16 @@ func (e *Chromium) MessageReceived(sender *ICoreWebView2, args *iCoreWebView2Web
 		log.Printf("WebView2 TryGetWebMessageAsString failed: %v", err)
 		return 0
 	}
-	if e.MessageCallback != nil {
+	if e.WebMessageCallback != nil {
+		source, err := args.GetSource()
+		if err != nil {
+			log.Printf("WebView2 GetSource failed: %v", err)
+			return 0
+		}
+		e.WebMessageCallback(message, source)
+	} else if e.MessageCallback != nil {
 		e.MessageCallback(message)
 	}
-	_ = sender.PostWebMessageAsString(message)
 	return 0
 }
 
@@ -325,6 +448,13 @@ func (e *Chromium) NavigationCompleted(sender *ICoreWebView2, args *ICoreWebView
 	return 0
 }
 
+func (e *Chromium) ContentLoading(sender *ICoreWebView2, args *ICoreWebView2ContentLoadingEventArgs) uintptr {
+	if e.ContentLoadingCallback != nil {
+		e.ContentLoadingCallback(sender, args)
+	}
+	return 0
+}
+
 func (e *Chromium) DownloadStarting(sender *ICoreWebView2, args *ICoreWebView2DownloadStartingEventArgs) uintptr {
 	if e.DownloadStartingCallback != nil {
 		e.DownloadStartingCallback(sender, args)
@@ -199,6 +199,25 @@ func (i *ICoreWebView2) AddScriptToExecuteOnDocumentCreated(javaScript string) e
 	return nil
 }
 
+// AddScriptToExecuteOnDocumentCreatedWithHandler is like
+// AddScriptToExecuteOnDocumentCreated, but reports the ID of the added script
+// to handler. The ID can be passed to RemoveScriptToExecuteOnDocumentCreated.
+func (i *ICoreWebView2) AddScriptToExecuteOnDocumentCreatedWithHandler(javaScript string, handler *ICoreWebView2AddScriptToExecuteOnDocumentCreatedCompletedHandler) error {
+	_js, err := windows.UTF16PtrFromString(javaScript)
+	if err != nil {
+		return err
+	}
+	_, _, err = i.vtbl.AddScriptToExecuteOnDocumentCreated.Call(
+		uintptr(unsafe.Pointer(i)),
+		uintptr(unsafe.Pointer(_js)),
+		uintptr(unsafe.Pointer(handler)),
+	)
+	if err != windows.ERROR_SUCCESS {
+		return err
+	}
+	return nil
+}
+
 // ExecuteScript runs the given JavaScript in the current document.
 // This helper intentionally ignores the optional completion handler.
 func (i *ICoreWebView2) ExecuteScript(javaScript string) error {
@@ -217,6 +236,24 @@ func (i *ICoreWebView2) ExecuteScript(javaScript string) error {
 	return nil
 }
 
+// ExecuteScriptWithHandler is like ExecuteScript, but reports the result of
+// the script, serialized as JSON, to handler.
+func (i *ICoreWebView2) ExecuteScriptWithHandler(javaScript string, handler *ICoreWebView2ExecuteScriptCompletedHandler) error {
+	_js, err := windows.UTF16PtrFromString(javaScript)
+	if err != nil {
+		return err
+	}
+	_, _, err = i.vtbl.ExecuteScript.Call(
+		uintptr(unsafe.Pointer(i)),
+		uintptr(unsafe.Pointer(_js)),
+		uintptr(unsafe.Pointer(handler)),
+	)
+	if err != windows.ERROR_SUCCESS {
+		return err
+	}
+	return nil
+}
+
 func (i *ICoreWebView2) PostWebMessageAsString(message string) error {
 	_msg, err := windows.UTF16PtrFromString(message)
 	if err != nil {
@@ -308,6 +345,8 @@ func (e *ICoreWebView2Environment) CreateWebResourceResponse(content []byte, sta
 		if err != nil {
 			return nil, err
 		}
+		// The response keeps its own reference to the stream.
+		defer releaseStream(stream)
 	}
 
 	// Convert string 'uri' to *uint16
@@ -336,6 +375,12 @@ func (e *ICoreWebView2Environment) CreateWebResourceResponse(content []byte, sta
 
 }
 
+// releaseStream releases the IStream created by SHCreateMemStream.
+func releaseStream(stream uintptr) {
+	unknown := *(**struct{ vtbl *_IUnknownVtbl })(unsafe.Pointer(&stream))
+	_, _, _ = unknown.vtbl.Release.Call(stream)
+}
+
 // ICoreWebView2WebMessageReceivedEventArgs
 
 type iCoreWebView2WebMessageReceivedEventArgsVtbl struct {
@@ -349,6 +394,24 @@ type iCoreWebView2WebMessageReceivedEventArgs struct {
 	vtbl *iCoreWebView2WebMessageReceivedEventArgsVtbl
 }
 
+// GetSource returns the URI of the document that posted the message.
+func (i *iCoreWebView2WebMessageReceivedEventArgs) GetSource() (string, error) {
+	var source *uint16
+	_, _, err := i.vtbl.GetSource.Call(
+		uintptr(unsafe.Pointer(i)),
+		uintptr(unsafe.Pointer(&source)),
+	)
+	if err != windows.ERROR_SUCCESS {
+		return "", err
+	}
+	if source == nil {
+		return "", nil
+	}
+	res := w32.Utf16PtrToString(source)
+	windows.CoTaskMemFree(unsafe.Pointer(source))
+	return res, nil
+}
+
 func (i *iCoreWebView2WebMessageReceivedEventArgs) TryGetWebMessageAsString() (string, error) {
 	var msg *uint16
 	_, _, err := i.vtbl.TryGetWebMessageAsString.Call(
@@ -0,0 +1,164 @@
+package tsgen
+
+import (
+	"context"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/logicossoftware/go-webview2/internal/bindtype"
+)
+
+var (
+	contextType       = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType         = reflect.TypeOf((*error)(nil)).Elem()
+	timeType          = reflect.TypeOf(time.Time{})
+	marshalerType     = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// Add adds the function fn, bound under name, to the declarations. A dot in
+// name places the function in a nested object, e.g. "app.files.read".
+func (g *Generator) Add(name string, fn interface{}) error {
+	t := reflect.TypeOf(fn)
+	if t == nil || t.Kind() != reflect.Func {
+		return errors.New("tsgen: only functions can be added")
+	}
+	f := function{path: strings.Split(name, "."), variadic: t.IsVariadic()}
+	for i := 0; i < t.NumIn(); i++ {
+		in := t.In(i)
+		if i == 0 && in == contextType || in.PkgPath() == webviewPath && in.Name() == "CallInfo" {
+			continue
+		}
+		if f.variadic && i == t.NumIn()-1 {
+			in = in.Elem()
+		}
+		typ := g.reflectType(in)
+		if bindtype.IsBinary(in) {
+			typ = binaryParam
+		}
+		f.params = append(f.params, param{name: paramName(len(f.params)), typ: typ})
+	}
+
+	f.result = "void"
+	if n := t.NumOut(); n > 0 {
+		last := t.Out(n - 1)
+		f.canReject = last.Implements(errorType)
+		if n == 2 || !f.canReject {
+			out := t.Out(0)
+			if bindtype.IsStream(out) {
+				f.stream = true
+				out = bindtype.StreamElem(out)
+			}
+			f.result = g.reflectType(out)
+			if bindtype.IsBinary(out) {
+				f.result = binaryResult
+			}
+		}
+	}
+	g.add(f)
+	return nil
+}
+
+func (g *Generator) reflectType(t reflect.Type) string {
+	switch {
+	case t == timeType:
+		return "string"
+	case bindtype.IsBytes(t):
+		return binaryResult
+	case t.PkgPath() == webviewPath && t.Name() == "JSFunc":
+		return funcType
+	case t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType):
+		return "unknown"
+	case t.Kind() != reflect.Pointer && (t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType)):
+		return "string"
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Interface:
+		return "any"
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// encoding/json marshals byte slices as base64 strings.
+			return "string"
+		}
+		return nullable(arrayOf(g.reflectType(t.Elem())))
+	case reflect.Array:
+		return arrayOf(g.reflectType(t.Elem()))
+	case reflect.Map:
+		key := "string"
+		switch t.Key().Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			key = "number"
+		}
+		return nullable(record(key, g.reflectType(t.Elem())))
+	case reflect.Pointer:
+		return nullable(g.reflectType(t.Elem()))
+	case reflect.Struct:
+		if t.Name() == "" {
+			return objectType(g.reflectFields(t), "")
+		}
+		return g.declare(t, t.Name(), func() []field { return g.reflectFields(t) })
+	default:
+		return "unknown"
+	}
+}
+
+// reflectFields returns the JSON fields of struct type t. Fields of embedded
+// structs without a json name are promoted like encoding/json does.
+func (g *Generator) reflectFields(t reflect.Type) []field {
+	var fields []field
+	seen := map[string]bool{}
+	var collect func(t reflect.Type)
+	collect = func(t reflect.Type) {
+		var promoted []reflect.Type
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			tag := sf.Tag.Get("json")
+			name, omitempty, asString, ok := jsonField(sf.Name, tag, sf.IsExported(), sf.Anonymous)
+			if !ok {
+				continue
+			}
+			ft := sf.Type
+			if tagName, _, _ := strings.Cut(tag, ","); sf.Anonymous && tagName == "" {
+				et := ft
+				if et.Kind() == reflect.Pointer {
+					et = et.Elem()
+				}
+				if et.Kind() == reflect.Struct {
+					promoted = append(promoted, et)
+					continue
+				}
+				if !sf.IsExported() {
+					continue
+				}
+			}
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			typ := g.reflectType(ft)
+			if asString {
+				typ = "string"
+			}
+			fields = append(fields, field{name: name, typ: typ, optional: omitempty})
+		}
+		for _, et := range promoted {
+			collect(et)
+		}
+	}
+	collect(t)
+	return fields
+}
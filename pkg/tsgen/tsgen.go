@@ -0,0 +1,325 @@
+// Package tsgen generates TypeScript declarations for Go functions bound to
+// a webview with Bind.
+//
+// Struct parameters and results are described as TypeScript interfaces,
+// using the field names, omitempty and string options of their json tags.
+// Functions that may return an error are declared as returning a promise
+// that rejects when the error is non-nil. Byte slice parameters and results,
+// and webview2.Bytes values, are exchanged as binary data.
+package tsgen
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// DefaultNamespace is the TypeScript namespace used for generated interfaces
+// when Generator.Namespace is empty.
+const DefaultNamespace = "Go"
+
+const webviewPath = "github.com/logicossoftware/go-webview2"
+
+// TypeScript types of binary data, see webview2.Bytes.
+const (
+	binaryParam  = "BufferSource"
+	binaryResult = "Uint8Array | null"
+)
+
+// funcType is the TypeScript type of a webview2.JSFunc.
+const funcType = "((...args: any[]) => unknown) | null"
+
+// builtins declares the types every generated file defines in the
+// namespace, for the methods of bound functions that set call options.
+const builtins = `	interface CallOptions {
+		/** Rejects the call with a GoError with code "timeout" after this many milliseconds. */
+		timeout?: number;
+		signal?: AbortSignal;
+		/** Trace ID passed to Go as CallInfo.TraceID, e.g. a W3C traceparent. */
+		trace?: string;
+	}
+
+	type Bound<F> = F & {
+		withTimeout(ms: number): F;
+		withOptions(options: CallOptions): F;
+	};
+`
+
+// builtinDecls are the names declared by builtins.
+var builtinDecls = map[string]bool{"CallOptions": true, "Bound": true}
+
+// Generator collects bound functions and writes a .d.ts file describing
+// them. The zero value is ready to use.
+type Generator struct {
+	// Namespace is the TypeScript namespace that holds the interfaces
+	// generated for Go structs. It keeps them from merging with DOM types of
+	// the same name.
+	Namespace string
+
+	funcs []function
+	decls map[string]string
+	names map[interface{}]string
+}
+
+type function struct {
+	path      []string
+	params    []param
+	variadic  bool
+	result    string
+	stream    bool
+	canReject bool
+}
+
+type param struct {
+	name string
+	typ  string
+}
+
+type field struct {
+	name     string
+	typ      string
+	optional bool
+}
+
+func (g *Generator) namespace() string {
+	if g.Namespace == "" {
+		return DefaultNamespace
+	}
+	return g.Namespace
+}
+
+// declare returns the qualified name of the interface generated for the
+// struct identified by id, generating it on first use. fields is called at
+// most once, after the name has been reserved, so recursive types work.
+func (g *Generator) declare(id interface{}, goName string, fields func() []field) string {
+	if g.names == nil {
+		g.names = map[interface{}]string{}
+		g.decls = map[string]string{}
+	}
+	if name, ok := g.names[id]; ok {
+		return g.namespace() + "." + name
+	}
+	base := identifier(goName)
+	name := base
+	for i := 2; ; i++ {
+		if _, taken := g.decls[name]; !taken && !builtinDecls[name] {
+			break
+		}
+		name = fmt.Sprintf("%s%d", base, i)
+	}
+	g.names[id] = name
+	g.decls[name] = ""
+	g.decls[name] = objectType(fields(), "\t")
+	return g.namespace() + "." + name
+}
+
+func (g *Generator) add(f function) {
+	g.funcs = append(g.funcs, f)
+}
+
+// Write writes the declarations of all added functions to w.
+func (g *Generator) Write(w io.Writer) error {
+	b := bufio.NewWriter(w)
+	fmt.Fprintln(b, "// Code generated by webview2-tsgen. DO NOT EDIT.")
+
+	names := make([]string, 0, len(g.decls))
+	for name := range g.decls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintf(b, "\ndeclare namespace %s {\n", g.namespace())
+	fmt.Fprint(b, builtins)
+	for _, name := range names {
+		fmt.Fprintf(b, "\n\tinterface %s %s\n", name, g.decls[name])
+	}
+	fmt.Fprintln(b, "}")
+
+	funcs := append([]function(nil), g.funcs...)
+	sort.SliceStable(funcs, func(i, j int) bool {
+		return strings.Join(funcs[i].path, ".") < strings.Join(funcs[j].path, ".")
+	})
+	fmt.Fprintln(b, "\ninterface Window {")
+	writeMembers(b, g.namespace(), funcs, 0, "\t")
+	fmt.Fprintln(b, "}")
+	return b.Flush()
+}
+
+// writeMembers writes the functions of funcs, which are sorted by path, at
+// the given depth of nested objects. ns is the namespace of the generated
+// types.
+func writeMembers(b *bufio.Writer, ns string, funcs []function, depth int, indent string) {
+	for i := 0; i < len(funcs); {
+		f := funcs[i]
+		if len(f.path) == depth+1 {
+			writeFunction(b, ns, f, indent)
+			i++
+			continue
+		}
+		j := i + 1
+		for j < len(funcs) && len(funcs[j].path) > depth+1 && funcs[j].path[depth] == f.path[depth] {
+			j++
+		}
+		fmt.Fprintf(b, "%s%s: {\n", indent, property(f.path[depth]))
+		writeMembers(b, ns, funcs[i:j], depth+1, indent+"\t")
+		fmt.Fprintf(b, "%s};\n", indent)
+		i = j
+	}
+}
+
+func writeFunction(b *bufio.Writer, ns string, f function, indent string) {
+	params := make([]string, 0, len(f.params)+1)
+	for i, p := range f.params {
+		if f.variadic && i == len(f.params)-1 {
+			params = append(params, "..."+p.name+": "+arrayOf(p.typ))
+		} else {
+			params = append(params, p.name+": "+p.typ)
+		}
+	}
+	if !f.variadic {
+		params = append(params, "signal?: AbortSignal")
+	}
+	result := "Promise<" + f.result + ">"
+	if f.stream {
+		result = "AsyncIterableIterator<" + f.result + ">"
+	}
+	if f.canReject {
+		fmt.Fprintf(b, "%s/** Rejects with a window.go.GoError if the Go function returns an error. */\n", indent)
+	}
+	fmt.Fprintf(b, "%s%s: %s.Bound<(%s) => %s>;\n", indent, property(f.path[len(f.path)-1]), ns, strings.Join(params, ", "), result)
+}
+
+func objectType(fields []field, indent string) string {
+	if len(fields) == 0 {
+		return "{}"
+	}
+	var sb strings.Builder
+	sb.WriteString("{\n")
+	for _, f := range fields {
+		sb.WriteString(indent + "\t" + property(f.name))
+		if f.optional {
+			sb.WriteString("?")
+		}
+		sb.WriteString(": " + f.typ + ";\n")
+	}
+	sb.WriteString(indent + "}")
+	return sb.String()
+}
+
+func arrayOf(typ string) string {
+	if strings.ContainsAny(typ, " |") {
+		return "(" + typ + ")[]"
+	}
+	return typ + "[]"
+}
+
+func nullable(typ string) string {
+	if typ == "any" || typ == "unknown" || strings.HasSuffix(typ, " | null") {
+		return typ
+	}
+	return typ + " | null"
+}
+
+func record(key, value string) string {
+	return "Record<" + key + ", " + value + ">"
+}
+
+// jsonField interprets the json struct tag of a field the way encoding/json
+// does. It reports false if the field is not marshaled.
+func jsonField(goName, tag string, exported, embedded bool) (name string, omitempty, asString, ok bool) {
+	if tag == "-" {
+		return "", false, false, false
+	}
+	if !exported && !embedded {
+		return "", false, false, false
+	}
+	name, opts, _ := strings.Cut(tag, ",")
+	for _, opt := range strings.Split(opts, ",") {
+		switch opt {
+		case "omitempty", "omitzero":
+			omitempty = true
+		case "string":
+			asString = true
+		}
+	}
+	if name == "" {
+		name = goName
+	}
+	return name, omitempty, asString, true
+}
+
+// identifier turns a Go type name, which may include type arguments, into a
+// TypeScript identifier.
+func identifier(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '_' || r == '$' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9':
+			sb.WriteRune(r)
+		case r == ']':
+		default:
+			sb.WriteRune('_')
+		}
+	}
+	if sb.Len() == 0 {
+		return "Anonymous"
+	}
+	return sb.String()
+}
+
+// property quotes name if it cannot be used as a bare property name.
+func property(name string) string {
+	if name == "" {
+		return `""`
+	}
+	for i, r := range name {
+		if !(r == '_' || r == '$' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || i > 0 && r >= '0' && r <= '9') {
+			return fmt.Sprintf("%q", name)
+		}
+	}
+	return name
+}
+
+// reservedParams are the names that cannot be used for parameters in
+// TypeScript, which checks declarations in strict mode, and signal, which
+// writeFunction declares for the AbortSignal. Go parameters with these
+// names get a trailing underscore.
+var reservedParams = map[string]bool{
+	"arguments": true, "await": true, "break": true, "case": true,
+	"catch": true, "class": true, "const": true, "continue": true,
+	"debugger": true, "default": true, "delete": true, "do": true,
+	"else": true, "enum": true, "eval": true, "export": true, "extends": true,
+	"false": true, "finally": true, "for": true, "function": true, "if": true,
+	"implements": true, "import": true, "in": true, "instanceof": true,
+	"interface": true, "let": true, "new": true, "null": true,
+	"package": true, "private": true, "protected": true, "public": true,
+	"return": true, "static": true, "super": true, "switch": true,
+	"this": true, "throw": true, "true": true, "try": true, "typeof": true,
+	"var": true, "void": true, "while": true, "with": true, "yield": true,
+	"signal": true,
+}
+
+func paramName(i int) string {
+	return fmt.Sprintf("arg%d", i)
+}
+
+// MethodName returns the JavaScript name under which BindObject exposes the
+// Go method goName: its leading upper-case letters are lowered, keeping the
+// last one of a longer run if it starts the next word, e.g. "Read" becomes
+// "read" and "HTTPGet" becomes "httpGet".
+func MethodName(goName string) string {
+	r := []rune(goName)
+	n := 0
+	for n < len(r) && unicode.IsUpper(r[n]) {
+		n++
+	}
+	if n > 1 && n < len(r) && unicode.IsLower(r[n]) {
+		n--
+	}
+	for i := 0; i < n; i++ {
+		r[i] = unicode.ToLower(r[i])
+	}
+	return string(r)
+}
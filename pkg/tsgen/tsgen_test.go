@@ -0,0 +1,188 @@
+package tsgen
+
+import (
+	"context"
+	"encoding/json"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+	"time"
+)
+
+// check type-checks src, a package named p, and returns its scope.
+func check(t *testing.T, src string) *types.Scope {
+	t.Helper()
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	pkg, err := conf.Check("p", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pkg.Scope()
+}
+
+func generate(t *testing.T, g *Generator) string {
+	t.Helper()
+	var sb strings.Builder
+	if err := g.Write(&sb); err != nil {
+		t.Fatal(err)
+	}
+	return sb.String()
+}
+
+func TestReservedParamNames(t *testing.T) {
+	scope := check(t, `package p
+func f(new string, class int, signal bool, ok string, _ int) {}
+`)
+	g := &Generator{}
+	if err := g.AddSignature("f", scope.Lookup("f").Type().(*types.Signature)); err != nil {
+		t.Fatal(err)
+	}
+	want := "f: Go.Bound<(new_: string, class_: number, signal_: boolean, ok: string, arg4: number, signal?: AbortSignal) => Promise<void>>;"
+	if out := generate(t, g); !strings.Contains(out, want) {
+		t.Errorf("declarations do not contain\n%s\ngot:\n%s", want, out)
+	}
+}
+
+type point struct {
+	X, Y int
+	Tags []string `json:"tags,omitempty"`
+}
+
+// TestReflectMatchesTypes checks that Add and AddSignature declare the same
+// functions, so that WriteTypeScript and webview2-tsgen agree.
+func TestReflectMatchesTypes(t *testing.T) {
+	funcs := map[string]interface{}{
+		"bytes":    func(b []byte) ([]byte, error) { return b, nil },
+		"raw":      func(m json.RawMessage) json.RawMessage { return m },
+		"chan":     func(ctx context.Context) (<-chan point, error) { return nil, nil },
+		"sendchan": func() chan<- int { return nil },
+		"seq":      func(n int) func(yield func(string) bool) { return nil },
+		"when":     func(t time.Time, d time.Duration) *time.Time { return nil },
+		"points":   func(p point, ps ...point) map[string]point { return nil },
+		"err":      func(a, b int) error { return nil },
+	}
+	scope := check(t, `package p
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+type point struct {
+	X, Y int
+	Tags []string `+"`json:\"tags,omitempty\"`"+`
+}
+
+func bytes([]byte) ([]byte, error) { return nil, nil }
+func raw(json.RawMessage) json.RawMessage { return nil }
+func chan_(context.Context) (<-chan point, error) { return nil, nil }
+func sendchan() chan<- int { return nil }
+func seq(int) func(yield func(string) bool) { return nil }
+func when(time.Time, time.Duration) *time.Time { return nil }
+func points(point, ...point) map[string]point { return nil }
+func err(_, _ int) error { return nil }
+`)
+	for name, fn := range funcs {
+		obj := name
+		if name == "chan" {
+			obj = "chan_"
+		}
+		byReflect, byTypes := &Generator{}, &Generator{}
+		if err := byReflect.Add(name, fn); err != nil {
+			t.Fatal(err)
+		}
+		if err := byTypes.AddSignature(name, scope.Lookup(obj).Type().(*types.Signature)); err != nil {
+			t.Fatal(err)
+		}
+		if r, s := generate(t, byReflect), generate(t, byTypes); r != s {
+			t.Errorf("%s: Add generated\n%s\nAddSignature generated\n%s", name, r, s)
+		}
+	}
+}
+
+type address struct {
+	Street string `json:"street"`
+	Zip    string `json:"zip,omitempty"`
+}
+
+type person struct {
+	Name     string         `json:"name"`
+	Age      int            `json:"age"`
+	Email    *string        `json:"email"`
+	Tags     []string       `json:"tags,omitempty"`
+	Address  address        `json:"address"`
+	Meta     map[string]int `json:"meta"`
+	Born     time.Time      `json:"born"`
+	Skip     string         `json:"-"`
+	Friends  []*person      `json:"friends"`
+	internal int
+}
+
+func TestWrite(t *testing.T) {
+	g := &Generator{}
+	for name, fn := range map[string]interface{}{
+		"app.people.get":  func(ctx context.Context, id int) (*person, error) { return nil, nil },
+		"app.people.list": func() <-chan person { return nil },
+		"upload":          func(data []byte, name string) error { return nil },
+		"sum":             func(xs ...float64) float64 { return 0 },
+	} {
+		if err := g.Add(name, fn); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := `
+	interface address {
+		street: string;
+		zip?: string;
+	}
+
+	interface person {
+		name: string;
+		age: number;
+		email: string | null;
+		tags?: string[] | null;
+		address: Go.address;
+		meta: Record<string, number> | null;
+		born: string;
+		friends: (Go.person | null)[] | null;
+	}
+}
+
+interface Window {
+	app: {
+		people: {
+			/** Rejects with a window.go.GoError if the Go function returns an error. */
+			get: Go.Bound<(arg0: number, signal?: AbortSignal) => Promise<Go.person | null>>;
+			list: Go.Bound<(signal?: AbortSignal) => AsyncIterableIterator<Go.person>>;
+		};
+	};
+	sum: Go.Bound<(...arg0: number[]) => Promise<number>>;
+	/** Rejects with a window.go.GoError if the Go function returns an error. */
+	upload: Go.Bound<(arg0: BufferSource, arg1: string, signal?: AbortSignal) => Promise<void>>;
+}
+`
+	out := generate(t, g)
+	if !strings.HasPrefix(out, "// Code generated by webview2-tsgen. DO NOT EDIT.\n") {
+		t.Errorf("declarations lack the generated code header:\n%s", out)
+	}
+	if !strings.HasSuffix(out, want) {
+		t.Errorf("declarations end in\n%s\nwant\n%s", out, want)
+	}
+}
+
+func TestAddRejectsNonFunctions(t *testing.T) {
+	g := &Generator{}
+	if err := g.Add("x", 1); err == nil {
+		t.Error("Add accepted an int")
+	}
+}
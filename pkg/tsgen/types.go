@@ -0,0 +1,272 @@
+package tsgen
+
+import (
+	"errors"
+	"go/types"
+	"reflect"
+	"strings"
+)
+
+var (
+	typesMarshaler     = methodSetInterface("MarshalJSON")
+	typesTextMarshaler = methodSetInterface("MarshalText")
+)
+
+// typesUnmarshalers are the interfaces of json.Unmarshaler and
+// encoding.TextUnmarshaler.
+var typesUnmarshalers = []*types.Interface{
+	unmarshalerInterface("UnmarshalJSON"),
+	unmarshalerInterface("UnmarshalText"),
+}
+
+// methodSetInterface returns an interface type with a single method name of
+// type func() ([]byte, error), which matches json.Marshaler and
+// encoding.TextMarshaler.
+func methodSetInterface(name string) *types.Interface {
+	bytes := types.NewSlice(types.Typ[types.Byte])
+	results := types.NewTuple(
+		types.NewVar(0, nil, "", bytes),
+		types.NewVar(0, nil, "", types.Universe.Lookup("error").Type()),
+	)
+	sig := types.NewSignatureType(nil, nil, nil, nil, results, false)
+	iface := types.NewInterfaceType([]*types.Func{types.NewFunc(0, nil, name, sig)}, nil)
+	return iface.Complete()
+}
+
+// unmarshalerInterface returns an interface type with a single method name
+// of type func([]byte) error.
+func unmarshalerInterface(name string) *types.Interface {
+	params := types.NewTuple(types.NewVar(0, nil, "", types.NewSlice(types.Typ[types.Byte])))
+	results := types.NewTuple(types.NewVar(0, nil, "", types.Universe.Lookup("error").Type()))
+	sig := types.NewSignatureType(nil, nil, nil, params, results, false)
+	iface := types.NewInterfaceType([]*types.Func{types.NewFunc(0, nil, name, sig)}, nil)
+	return iface.Complete()
+}
+
+// AddSignature is like Add, but takes the function type from type-checked Go
+// source instead of a function value. Parameter names of sig are used when
+// present.
+func (g *Generator) AddSignature(name string, sig *types.Signature) error {
+	if sig == nil {
+		return errors.New("tsgen: nil signature")
+	}
+	f := function{path: strings.Split(name, "."), variadic: sig.Variadic()}
+	params := sig.Params()
+	for i := 0; i < params.Len(); i++ {
+		v := params.At(i)
+		t := v.Type()
+		if i == 0 && isNamed(t, "context", "Context") || isNamed(t, webviewPath, "CallInfo") {
+			continue
+		}
+		if f.variadic && i == params.Len()-1 {
+			t = t.(*types.Slice).Elem()
+		}
+		pname := v.Name()
+		if pname == "" || pname == "_" {
+			pname = paramName(len(f.params))
+		} else if reservedParams[pname] {
+			pname += "_"
+		}
+		typ := g.goType(t)
+		if typesIsBinary(t) {
+			typ = binaryParam
+		}
+		f.params = append(f.params, param{name: pname, typ: typ})
+	}
+
+	f.result = "void"
+	results := sig.Results()
+	if n := results.Len(); n > 0 {
+		f.canReject = types.Implements(results.At(n-1).Type(), types.Universe.Lookup("error").Type().Underlying().(*types.Interface))
+		if n == 2 || !f.canReject {
+			out := results.At(0).Type()
+			if elem, ok := typesStreamElem(out); ok {
+				f.stream = true
+				out = elem
+			}
+			f.result = g.goType(out)
+			if typesIsBinary(out) {
+				f.result = binaryResult
+			}
+		}
+	}
+	g.add(f)
+	return nil
+}
+
+func isNamed(t types.Type, pkg, name string) bool {
+	n, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := n.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == pkg && obj.Name() == name
+}
+
+// typesStreamElem returns the item type if t is a receive channel or shaped
+// like iter.Seq. It mirrors bindtype.IsStream and bindtype.StreamElem.
+func typesStreamElem(t types.Type) (types.Type, bool) {
+	switch u := t.Underlying().(type) {
+	case *types.Chan:
+		if u.Dir() != types.SendOnly {
+			return u.Elem(), true
+		}
+	case *types.Signature:
+		if u.Params().Len() != 1 || u.Results().Len() != 0 {
+			return nil, false
+		}
+		yield, ok := u.Params().At(0).Type().Underlying().(*types.Signature)
+		if !ok || yield.Params().Len() != 1 || yield.Results().Len() != 1 {
+			return nil, false
+		}
+		if b, ok := yield.Results().At(0).Type().Underlying().(*types.Basic); ok && b.Kind() == types.Bool {
+			return yield.Params().At(0).Type(), true
+		}
+	}
+	return nil, false
+}
+
+// typesIsBinary mirrors bindtype.IsBinary for type-checked types.
+func typesIsBinary(t types.Type) bool {
+	s, ok := t.Underlying().(*types.Slice)
+	if !ok {
+		return false
+	}
+	if b, ok := s.Elem().(*types.Basic); !ok || b.Kind() != types.Byte {
+		return false
+	}
+	if isNamed(t, webviewPath, "Bytes") {
+		return true
+	}
+	for _, iface := range append([]*types.Interface{typesMarshaler, typesTextMarshaler}, typesUnmarshalers...) {
+		if implements(t, iface) {
+			return false
+		}
+	}
+	return true
+}
+
+func implements(t types.Type, iface *types.Interface) bool {
+	if types.Implements(t, iface) {
+		return true
+	}
+	if _, isPtr := t.Underlying().(*types.Pointer); isPtr {
+		return false
+	}
+	return types.Implements(types.NewPointer(t), iface)
+}
+
+func (g *Generator) goType(t types.Type) string {
+	_, isPtr := t.Underlying().(*types.Pointer)
+	switch {
+	case isNamed(t, "time", "Time"):
+		return "string"
+	case isNamed(t, webviewPath, "Bytes"):
+		return binaryResult
+	case isNamed(t, webviewPath, "JSFunc"):
+		return funcType
+	case implements(t, typesMarshaler):
+		return "unknown"
+	case !isPtr && implements(t, typesTextMarshaler):
+		return "string"
+	}
+
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsBoolean != 0:
+			return "boolean"
+		case u.Info()&types.IsNumeric != 0 && u.Info()&types.IsComplex == 0:
+			return "number"
+		case u.Info()&types.IsString != 0:
+			return "string"
+		}
+		return "unknown"
+	case *types.Interface:
+		return "any"
+	case *types.Slice:
+		if b, ok := u.Elem().Underlying().(*types.Basic); ok && b.Kind() == types.Byte {
+			// encoding/json marshals byte slices as base64 strings.
+			return "string"
+		}
+		return nullable(arrayOf(g.goType(u.Elem())))
+	case *types.Array:
+		return arrayOf(g.goType(u.Elem()))
+	case *types.Map:
+		key := "string"
+		if b, ok := u.Key().Underlying().(*types.Basic); ok && b.Info()&types.IsInteger != 0 {
+			key = "number"
+		}
+		return nullable(record(key, g.goType(u.Elem())))
+	case *types.Pointer:
+		return nullable(g.goType(u.Elem()))
+	case *types.Struct:
+		named, ok := t.(*types.Named)
+		if !ok {
+			return objectType(g.goFields(u), "")
+		}
+		name := named.Obj().Name()
+		if args := named.TypeArgs(); args != nil && args.Len() > 0 {
+			parts := make([]string, args.Len())
+			for i := range parts {
+				parts[i] = types.TypeString(args.At(i), func(p *types.Package) string { return p.Name() })
+			}
+			name += "[" + strings.Join(parts, ",") + "]"
+		}
+		return g.declare(typeKey(named), name, func() []field { return g.goFields(u) })
+	default:
+		return "unknown"
+	}
+}
+
+// typeKey identifies a named type across instantiations with identical type
+// arguments, which are distinct *types.Named values.
+func typeKey(n *types.Named) interface{} {
+	return types.TypeString(n, nil)
+}
+
+// goFields mirrors reflectFields for type-checked structs.
+func (g *Generator) goFields(s *types.Struct) []field {
+	var fields []field
+	seen := map[string]bool{}
+	var collect func(s *types.Struct)
+	collect = func(s *types.Struct) {
+		var promoted []*types.Struct
+		for i := 0; i < s.NumFields(); i++ {
+			v := s.Field(i)
+			tag := reflect.StructTag(s.Tag(i)).Get("json")
+			name, omitempty, asString, ok := jsonField(v.Name(), tag, v.Exported(), v.Embedded())
+			if !ok {
+				continue
+			}
+			ft := v.Type()
+			if tagName, _, _ := strings.Cut(tag, ","); v.Embedded() && tagName == "" {
+				et := ft
+				if p, ok := et.Underlying().(*types.Pointer); ok {
+					et = p.Elem()
+				}
+				if st, ok := et.Underlying().(*types.Struct); ok {
+					promoted = append(promoted, st)
+					continue
+				}
+				if !v.Exported() {
+					continue
+				}
+			}
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			typ := g.goType(ft)
+			if asString {
+				typ = "string"
+			}
+			fields = append(fields, field{name: name, typ: typ, optional: omitempty})
+		}
+		for _, st := range promoted {
+			collect(st)
+		}
+	}
+	collect(s)
+	return fields
+}
@@ -0,0 +1,121 @@
+package webview2
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"sync"
+	"time"
+)
+
+// Kinds of TraceEntry.
+const (
+	// TraceMessage is a message posted by JavaScript: a call, a
+	// cancellation, stream credit, the result of a JSFunc or an event.
+	TraceMessage = "message"
+
+	// TraceResponse is the result or error of a call of a bound function.
+	TraceResponse = "response"
+
+	// TraceItem is an item of a streaming result.
+	TraceItem = "item"
+
+	// TraceEmit is an event emitted by Go.
+	TraceEmit = "emit"
+
+	// TraceEval is a script evaluated with Eval or EvalResult.
+	TraceEval = "eval"
+
+	// TracePost is a JSON-RPC response posted to the document.
+	TracePost = "post"
+
+	// TraceNavigation is the start of a new document, which cancels the
+	// calls of the previous one.
+	TraceNavigation = "navigation"
+)
+
+// TraceEntry is a line of a trace written to WebViewOptions.Recorder.
+// Values are recorded as JSON whatever the codec.
+type TraceEntry struct {
+	Time time.Time `json:"time"`
+	Kind string    `json:"kind"`
+
+	// Source and Message are set for TraceMessage entries. Message is the
+	// message as posted; TracePost entries also set it. TraceNavigation
+	// entries set Source to the URI of the new document.
+	Source  string `json:"source,omitempty"`
+	Message string `json:"message,omitempty"`
+
+	// Navigation is the navigation ID of TraceNavigation entries.
+	Navigation uint64 `json:"navigation,omitempty"`
+
+	// ID is the call of TraceResponse and TraceItem entries.
+	ID int `json:"id,omitempty"`
+
+	// Topic is set for TraceEmit entries.
+	Topic string `json:"topic,omitempty"`
+
+	// Value is the result, item or payload, and Error the GoError a call
+	// failed with.
+	Value json.RawMessage `json:"value,omitempty"`
+	Error json.RawMessage `json:"error,omitempty"`
+
+	// Script is set for TraceEval entries.
+	Script string `json:"script,omitempty"`
+}
+
+// recorder writes a trace of the traffic of the bridge as JSON lines.
+type recorder struct {
+	m      sync.Mutex
+	enc    *json.Encoder
+	failed bool
+
+	// sink, if set, receives the entries as well; enc may be nil.
+	sink func(TraceEntry)
+}
+
+func newRecorder(w io.Writer) *recorder {
+	if w == nil {
+		return nil
+	}
+	return &recorder{enc: json.NewEncoder(w)}
+}
+
+func (r *recorder) record(e TraceEntry) {
+	if r == nil {
+		return
+	}
+	e.Time = time.Now()
+	r.m.Lock()
+	defer r.m.Unlock()
+	if r.sink != nil {
+		r.sink(e)
+	}
+	if r.enc == nil {
+		return
+	}
+	if err := r.enc.Encode(e); err != nil && !r.failed {
+		r.failed = true
+		log.Printf("recording bridge traffic failed: %v", err)
+	}
+}
+
+// recordValue records an entry of kind with value v, or with err if it is
+// not nil.
+func (r *recorder) recordValue(e TraceEntry, v interface{}, err error) {
+	if r == nil {
+		return
+	}
+	if err == nil {
+		e.Value, err = json.Marshal(v)
+	}
+	if err != nil {
+		e.Value = nil
+		je := newJSError(err)
+		if e.Error, err = json.Marshal(je); err != nil {
+			je.Data = nil
+			e.Error, _ = json.Marshal(je)
+		}
+	}
+	r.record(e)
+}
@@ -0,0 +1,343 @@
+package webview2
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const defaultReplayTimeout = 10 * time.Second
+
+var errNoBrowser = errors.New("scripts cannot be evaluated when replaying a trace")
+
+// ReplayOptions configures a Replayer. The codec, execution and JSON-RPC
+// options should match the WebViewOptions the trace was recorded with.
+type ReplayOptions struct {
+	Codec            Codec
+	BindingExecution BindingExecution
+	BindingWorkers   int
+	JSONRPC          bool
+
+	// Timeout bounds how long Replay waits for the responses of a trace. If
+	// zero, it is 10 seconds.
+	Timeout time.Duration
+}
+
+// Replayer feeds a trace written to WebViewOptions.Recorder into bound
+// functions without a browser, and compares the responses with the trace.
+// Functions and middleware are registered as with a WebView.
+type Replayer struct {
+	w       *webview
+	timeout time.Duration
+
+	// changed is signalled when an entry is recorded, a call finishes or
+	// the timeout of Replay expires.
+	m       sync.Mutex
+	changed *sync.Cond
+	got     []TraceEntry
+	counts  map[string]int // of got, by entryKey
+	expired bool
+}
+
+// ReplayDiff is a response that differs from the trace. Want is nil for
+// responses missing from the trace, and Got is nil for responses that were
+// not made when replaying it.
+type ReplayDiff struct {
+	Want *TraceEntry
+	Got  *TraceEntry
+}
+
+func (d ReplayDiff) String() string {
+	e := d.Want
+	if e == nil {
+		e = d.Got
+	}
+	switch {
+	case d.Want == nil:
+		return "unexpected " + describeEntry(e)
+	case d.Got == nil:
+		return "missing " + describeEntry(e)
+	}
+	return fmt.Sprintf("%s: want %s, got %s", describeEntry(e), entryOutput(d.Want), entryOutput(d.Got))
+}
+
+// NewReplayer creates a Replayer using the provided options. It returns nil
+// if the options are invalid.
+func NewReplayer(options ReplayOptions) *Replayer {
+	r := &Replayer{timeout: options.Timeout}
+	r.changed = sync.NewCond(&r.m)
+	if r.timeout == 0 {
+		r.timeout = defaultReplayTimeout
+	}
+	w, err := newWebview(WebViewOptions{
+		Codec:            options.Codec,
+		BindingExecution: options.BindingExecution,
+		BindingWorkers:   options.BindingWorkers,
+		JSONRPC:          options.JSONRPC,
+	})
+	if err != nil {
+		log.Printf("failed to create replayer: %v", err)
+		return nil
+	}
+	w.headless = true
+	w.browser = replayBrowser{}
+	w.recorder = &recorder{sink: func(e TraceEntry) {
+		if isReplayed(e.Kind) {
+			r.m.Lock()
+			r.got = append(r.got, e)
+			r.counts[entryKey(&e)]++
+			r.m.Unlock()
+			r.changed.Broadcast()
+		}
+	}}
+	w.callFinished = func() {
+		// Taking the lock orders the signal after the check of a waiter.
+		r.m.Lock()
+		r.m.Unlock()
+		r.changed.Broadcast()
+	}
+	r.w = w
+	return r
+}
+
+func (r *Replayer) Bind(name string, f interface{}) error { return r.w.Bind(name, f) }
+
+func (r *Replayer) BindWithOptions(name string, f interface{}, opts BindOptions) error {
+	return r.w.BindWithOptions(name, f, opts)
+}
+
+func (r *Replayer) BindObject(namespace string, v interface{}) error {
+	return r.w.BindObject(namespace, v)
+}
+
+func (r *Replayer) BindObjectWithOptions(namespace string, v interface{}, opts BindOptions) error {
+	return r.w.BindObjectWithOptions(namespace, v, opts)
+}
+
+func (r *Replayer) Use(mw ...BindingMiddleware) { r.w.Use(mw...) }
+
+func (r *Replayer) On(pattern string, handler interface{}) (func(), error) {
+	return r.w.On(pattern, handler)
+}
+
+// Replay posts the messages of trace in order and waits until the calls
+// they made are settled, or the timeout expires. Before posting a message,
+// it waits until the responses, stream items, events and JSON-RPC posts
+// recorded before it have been made again, so that cancellations and
+// stream credits reach calls in the state they were recorded in. It
+// returns the entries that differ from those in the trace. Entries are
+// matched by kind and call ID or topic, in order, so calls may complete in
+// a different order than they were recorded. Navigations cancel the calls
+// of the previous document as they did when the trace was recorded. Evals
+// and timestamps are not compared.
+func (r *Replayer) Replay(trace io.Reader) ([]ReplayDiff, error) {
+	type step struct {
+		entry  TraceEntry     // a message or navigation
+		before map[string]int // entries recorded before entry, by entryKey
+	}
+	var steps []step
+	var want []TraceEntry
+	wantCounts := map[string]int{}
+	dec := json.NewDecoder(trace)
+	for {
+		var e TraceEntry
+		if err := dec.Decode(&e); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("invalid trace: %v", err)
+		}
+		switch {
+		case e.Kind == TraceMessage || e.Kind == TraceNavigation:
+			before := make(map[string]int, len(wantCounts))
+			for k, n := range wantCounts {
+				before[k] = n
+			}
+			steps = append(steps, step{e, before})
+		case isReplayed(e.Kind):
+			want = append(want, e)
+			wantCounts[entryKey(&e)]++
+		}
+	}
+
+	r.m.Lock()
+	r.got = nil
+	r.counts = map[string]int{}
+	r.expired = false
+	r.m.Unlock()
+	timer := time.AfterFunc(r.timeout, func() {
+		r.m.Lock()
+		r.expired = true
+		r.m.Unlock()
+		r.changed.Broadcast()
+	})
+	defer timer.Stop()
+
+	for _, s := range steps {
+		r.wait(s.before, false)
+		if s.entry.Kind == TraceNavigation {
+			r.w.documentLoading(s.entry.Navigation, s.entry.Source)
+		} else {
+			r.w.msgcb(s.entry.Message, s.entry.Source)
+		}
+	}
+	r.wait(wantCounts, true)
+	// Calls still running are cancelled, and their responses dropped.
+	r.w.cancelPending()
+
+	r.m.Lock()
+	got := r.got
+	r.got = nil
+	r.m.Unlock()
+	return diffTraces(want, got), nil
+}
+
+// wait waits until the entries counted by want have been made again and, if
+// idle is set, no call is pending, or until the timeout of Replay expires.
+func (r *Replayer) wait(want map[string]int, idle bool) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	for !r.expired && !r.caughtUp(want, idle) {
+		r.changed.Wait()
+	}
+}
+
+func (r *Replayer) caughtUp(want map[string]int, idle bool) bool {
+	for k, n := range want {
+		if r.counts[k] < n {
+			return false
+		}
+	}
+	if !idle {
+		return true
+	}
+	r.w.m.Lock()
+	defer r.w.m.Unlock()
+	return len(r.w.pending) == 0
+}
+
+func isReplayed(kind string) bool {
+	switch kind {
+	case TraceResponse, TraceItem, TraceEmit, TracePost:
+		return true
+	}
+	return false
+}
+
+// diffTraces compares the entries of want and got with the same key in
+// order.
+func diffTraces(want, got []TraceEntry) []ReplayDiff {
+	var keys []string
+	wants := map[string][]*TraceEntry{}
+	gots := map[string][]*TraceEntry{}
+	for i := range want {
+		k := entryKey(&want[i])
+		if _, ok := wants[k]; !ok {
+			keys = append(keys, k)
+		}
+		wants[k] = append(wants[k], &want[i])
+	}
+	for i := range got {
+		k := entryKey(&got[i])
+		if _, ok := wants[k]; !ok {
+			if _, ok := gots[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		gots[k] = append(gots[k], &got[i])
+	}
+
+	var diffs []ReplayDiff
+	for _, k := range keys {
+		w, g := wants[k], gots[k]
+		for i := 0; i < len(w) || i < len(g); i++ {
+			var d ReplayDiff
+			if i < len(w) {
+				d.Want = w[i]
+			}
+			if i < len(g) {
+				d.Got = g[i]
+			}
+			if d.Want == nil || d.Got == nil || !sameEntry(d.Want, d.Got) {
+				diffs = append(diffs, d)
+			}
+		}
+	}
+	return diffs
+}
+
+func entryKey(e *TraceEntry) string {
+	switch e.Kind {
+	case TraceResponse, TraceItem:
+		return e.Kind + " " + strconv.Itoa(e.ID)
+	case TraceEmit:
+		return e.Kind + " " + e.Topic
+	}
+	return e.Kind
+}
+
+func sameEntry(a, b *TraceEntry) bool {
+	return sameJSON(a.Value, b.Value) && sameJSON(a.Error, b.Error) && sameJSON([]byte(a.Message), []byte(b.Message))
+}
+
+// sameJSON reports whether a and b encode the same value, regardless of
+// the order of object members and the formatting.
+func sameJSON(a, b []byte) bool {
+	if bytes.Equal(a, b) {
+		return true
+	}
+	var va, vb interface{}
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+func describeEntry(e *TraceEntry) string {
+	switch e.Kind {
+	case TraceResponse:
+		return "response to call " + strconv.Itoa(e.ID)
+	case TraceItem:
+		return "item of call " + strconv.Itoa(e.ID)
+	case TraceEmit:
+		return "event " + strconv.Quote(e.Topic)
+	}
+	return "JSON-RPC post"
+}
+
+func entryOutput(e *TraceEntry) string {
+	switch {
+	case e.Error != nil:
+		return "error " + string(e.Error)
+	case e.Kind == TracePost:
+		return e.Message
+	}
+	return string(e.Value)
+}
+
+// replayBrowser stands in for WebView2 in a Replayer. Scripts are dropped.
+type replayBrowser struct{}
+
+func (replayBrowser) Embed(hwnd uintptr) bool      { return true }
+func (replayBrowser) Resize()                      {}
+func (replayBrowser) Navigate(url string)          {}
+func (replayBrowser) NavigateToString(html string) {}
+func (replayBrowser) Init(script string)           {}
+func (replayBrowser) AddInitScript(script string, done func(id string, err error)) {
+	done("", nil)
+}
+func (replayBrowser) RemoveInitScript(id string) error  { return nil }
+func (replayBrowser) Eval(script string)                {}
+func (replayBrowser) PostMessage(message string)        {}
+func (replayBrowser) PostMessageJSON(json string) error { return nil }
+func (replayBrowser) EvalWithResult(script string, done func(result string, err error)) {
+	done("", errNoBrowser)
+}
+func (replayBrowser) NotifyParentWindowPositionChanged() error { return nil }
+func (replayBrowser) Focus()                                   {}
@@ -0,0 +1,119 @@
+package webview2
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"iter"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReplay(t *testing.T) {
+	var trace bytes.Buffer
+	f := NewFake(WebViewOptions{Recorder: &trace})
+	if err := f.Bind("add", func(a, b int) int { return a + b }); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]interface{}{{1, 2}, {3, 4}} {
+		if _, err := f.Call(context.Background(), "add", args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := NewReplayer(ReplayOptions{})
+	if err := r.Bind("add", func(a, b int) int { return a + b }); err != nil {
+		t.Fatal(err)
+	}
+	diffs, err := r.Replay(bytes.NewReader(trace.Bytes()))
+	if err != nil || len(diffs) != 0 {
+		t.Errorf("Replay = %v, %v, want no differences", diffs, err)
+	}
+
+	r = NewReplayer(ReplayOptions{})
+	if err := r.Bind("add", func(a, b int) int { return a - b }); err != nil {
+		t.Fatal(err)
+	}
+	diffs, err = r.Replay(bytes.NewReader(trace.Bytes()))
+	if err != nil || len(diffs) != 2 {
+		t.Errorf("Replay of a changed function = %v, %v, want 2 differences", diffs, err)
+	}
+}
+
+// TestReplayCancel replays a stream JavaScript left after three items. The
+// cancellation must not be posted before the items were sent again.
+func TestReplayCancel(t *testing.T) {
+	trace := strings.Join([]string{
+		`{"kind": "message", "message": "{\"id\": 1, \"method\": \"count\", \"params\": [], \"credit\": 3}"}`,
+		`{"kind": "item", "id": 1, "value": 1}`,
+		`{"kind": "item", "id": 1, "value": 2}`,
+		`{"kind": "item", "id": 1, "value": 3}`,
+		`{"kind": "message", "message": "{\"id\": 1, \"cancel\": true}"}`,
+	}, "\n")
+	r := NewReplayer(ReplayOptions{BindingExecution: BindingExecutionGoroutine, Timeout: 5 * time.Second})
+	err := r.Bind("count", func() iter.Seq[int] {
+		return func(yield func(int) bool) {
+			// Give an early cancellation time to arrive.
+			time.Sleep(10 * time.Millisecond)
+			for i := 1; yield(i); i++ {
+			}
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	diffs, err := r.Replay(strings.NewReader(trace))
+	if err != nil || len(diffs) != 0 {
+		t.Errorf("Replay = %v, %v, want no differences", diffs, err)
+	}
+}
+
+// TestReplayNavigation replays a trace of two documents. The call the
+// first document left pending must be cancelled by the navigation, and
+// calls of the second document must see its navigation.
+func TestReplayNavigation(t *testing.T) {
+	canceled, err := json.Marshal(newJSError(context.Canceled))
+	if err != nil {
+		t.Fatal(err)
+	}
+	trace := strings.Join([]string{
+		`{"kind": "navigation", "source": "https://a.example/", "navigation": 1}`,
+		`{"kind": "message", "source": "https://a.example/", "message": "{\"id\": 1, \"method\": \"wait\", \"params\": []}"}`,
+		`{"kind": "navigation", "source": "https://b.example/", "navigation": 2}`,
+		`{"kind": "response", "id": 1, "error": ` + string(canceled) + `}`,
+		`{"kind": "message", "source": "https://b.example/", "message": "{\"id\": 1, \"method\": \"where\", \"params\": []}"}`,
+		`{"kind": "response", "id": 1, "value": [2, "https://b.example"]}`,
+	}, "\n")
+	r := NewReplayer(ReplayOptions{BindingExecution: BindingExecutionGoroutine, Timeout: 2 * time.Second})
+	if err := r.Bind("wait", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Bind("where", func(info CallInfo) []interface{} {
+		return []interface{}{info.NavigationID, info.Origin}
+	}); err != nil {
+		t.Fatal(err)
+	}
+	diffs, err := r.Replay(strings.NewReader(trace))
+	if err != nil || len(diffs) != 0 {
+		t.Errorf("Replay = %v, %v, want no differences", diffs, err)
+	}
+}
+
+func TestRecordNavigation(t *testing.T) {
+	f := NewFake(WebViewOptions{})
+	f.Navigate("https://a.example/")
+	f.Navigate("https://b.example/")
+	var got []TraceEntry
+	for _, e := range f.Trace() {
+		if e.Kind == TraceNavigation {
+			got = append(got, e)
+		}
+	}
+	if len(got) != 2 || got[0].Source != "https://a.example/" || got[1].Navigation != 2 {
+		t.Errorf("recorded navigations %+v", got)
+	}
+}
@@ -0,0 +1,509 @@
+package webview2
+
+import (
+	"context"
+	_ "embed"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// rpcScript is the JavaScript side of the RPC bridge. It is injected into
+// every document before any binding stubs.
+//
+//go:embed rpc.js
+var rpcScript string
+
+// codecScript implements the binary codecs for the JavaScript side of the
+// bridge. It is only injected if one of them is used.
+//
+//go:embed codec.js
+var codecScript string
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+var errDocumentGone = errors.New("the document was unloaded")
+
+// paramsError is returned by callbinding if the arguments of a call do not
+// fit the bound function.
+type paramsError struct {
+	err error
+}
+
+func (e *paramsError) Error() string { return e.err.Error() }
+func (e *paramsError) Unwrap() error { return e.err }
+
+type rpcMessage struct {
+	ID     int               `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+	Cancel bool              `json:"cancel,omitempty"`
+	Credit int               `json:"credit,omitempty"`
+
+	// Timeout is the timeout of a call in milliseconds, or 0.
+	Timeout int `json:"timeout,omitempty"`
+
+	// Trace is the trace ID of a call, see CallInfo.TraceID.
+	Trace string `json:"trace,omitempty"`
+
+	// Callback identifies the call of a JSFunc whose result the message
+	// carries.
+	Callback int             `json:"callback,omitempty"`
+	Result   json.RawMessage `json:"result,omitempty"`
+	Error    *JSError        `json:"error,omitempty"`
+
+	// Event is the topic of an event emitted by JavaScript.
+	Event   string          `json:"event,omitempty"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// pendingCall tracks a binding call that has not completed yet.
+type pendingCall struct {
+	cancel     context.CancelFunc
+	generation uint64
+
+	// credit is the number of stream items JavaScript is willing to
+	// buffer. It is guarded by webview.m.
+	credit int
+	wake   chan struct{}
+
+	// stats observes the call for the metrics of its binding.
+	stats *callStats
+
+	// answered is set by the first response. A function that returns after
+	// its call timed out is not answered again.
+	answered atomic.Bool
+
+	// aborted is set when JavaScript cancelled the call. It already
+	// rejected the promise, so the result is not delivered.
+	aborted atomic.Bool
+}
+
+func jsString(v interface{}) string { b, _ := json.Marshal(v); return string(b) }
+
+func (w *webview) msgcb(msg string, source string) {
+	w.recorder.record(TraceEntry{Kind: TraceMessage, Source: source, Message: msg})
+	if w.jsonrpc && isJSONRPC(msg) {
+		w.serveJSONRPC(msg, source)
+		return
+	}
+
+	d := rpcMessage{}
+	if err := w.unmarshalMessage(msg, &d); err != nil {
+		log.Printf("invalid RPC message: %v", err)
+		w.metrics.invalidMessage()
+		return
+	}
+
+	if d.Cancel {
+		w.cancelCall(d.ID)
+		return
+	}
+	if d.Method == "" && d.Credit > 0 {
+		w.addCredit(d.ID, d.Credit)
+		return
+	}
+	if d.Callback > 0 {
+		w.settleCallback(d)
+		return
+	}
+	if d.Event != "" {
+		if w.eventAllowed(d.Event, originOf(source)) {
+			w.dispatchEvent(d.Event, d.Payload, source)
+		}
+		return
+	}
+
+	timeout := w.callTimeout(d.Method, time.Duration(d.Timeout)*time.Millisecond)
+	ctx, call := w.startCall(d.Method, d.ID, d.Credit, timeout)
+	bc := &BindingCall{
+		CallInfo: w.callInfo(d.Method, source, d.ID),
+		Params:   d.Params,
+		Codec:    w.codec,
+	}
+	bc.TraceID = d.Trace
+	bc.Context = w.startSpan(ctx, bc.CallInfo, call)
+	w.watchDeadline(ctx, d.Method, timeout, func(err error) {
+		w.finishCall(d.ID, call)
+		w.respond(call, d.ID, nil, err)
+	})
+	if !w.callAllowed(bc.Method, bc.Origin) {
+		err := w.rejectCall(bc.CallInfo)
+		w.finishCall(d.ID, call)
+		w.respond(call, d.ID, nil, err)
+		return
+	}
+	w.executor.execute(d.Method, func() {
+		res, err := w.invoke(bc, call)
+		if err == nil {
+			if s, ok := streamValue(res); ok {
+				go w.stream(ctx, bc.CallInfo, d.ID, call, s)
+				return
+			}
+		}
+		w.finishCall(d.ID, call)
+		w.respond(call, d.ID, res, err)
+	})
+}
+
+// nextInternalID returns the ID of a call not made by the JavaScript
+// runtime, such as a JSON-RPC call or a call of Fake.Call. These IDs are
+// negative so they do not collide with those of the JavaScript runtime.
+func (w *webview) nextInternalID() int {
+	w.m.Lock()
+	defer w.m.Unlock()
+	w.internalCallID--
+	return w.internalCallID
+}
+
+// startCall registers call id of method by the current document and returns
+// the context passed to context-aware bindings. If timeout is positive, the
+// context has a deadline.
+func (w *webview) startCall(method string, id int, credit int, timeout time.Duration) (context.Context, *pendingCall) {
+	ctx, cancel := context.WithCancel(context.Background())
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), timeout)
+	}
+	stats := w.startStats(method)
+	w.m.Lock()
+	call := &pendingCall{cancel: cancel, generation: w.generation, credit: credit, wake: make(chan struct{}, 1), stats: stats}
+	w.pending[id] = call
+	w.m.Unlock()
+	return ctx, call
+}
+
+func (w *webview) finishCall(id int, call *pendingCall) {
+	w.m.Lock()
+	if w.pending[id] == call {
+		delete(w.pending, id)
+	}
+	w.m.Unlock()
+	call.cancel()
+	if w.callFinished != nil {
+		w.callFinished()
+	}
+}
+
+// callTimeout returns the timeout of a call of method: the shorter of the
+// timeout requested by JavaScript and the one of the binding, if any.
+func (w *webview) callTimeout(method string, requested time.Duration) time.Duration {
+	w.m.Lock()
+	var timeout time.Duration
+	if b, ok := w.bindings[method]; ok {
+		timeout = b.timeout
+	}
+	w.m.Unlock()
+	if requested > 0 && (timeout <= 0 || requested < timeout) {
+		return requested
+	}
+	return timeout
+}
+
+// watchDeadline calls fail with a timeout error when the deadline of ctx
+// passes, without waiting for the bound function to return. Its eventual
+// result is discarded.
+func (w *webview) watchDeadline(ctx context.Context, method string, timeout time.Duration, fail func(error)) {
+	if timeout <= 0 {
+		return
+	}
+	context.AfterFunc(ctx, func() {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			fail(&Error{
+				Code:    "timeout",
+				Status:  504,
+				Message: fmt.Sprintf("call of %s timed out after %v", method, timeout),
+				Err:     context.DeadlineExceeded,
+			})
+		}
+	})
+}
+
+// cancelCall cancels the context of call id after JavaScript aborted it,
+// and drops the blobs of its results that were not fetched.
+func (w *webview) cancelCall(id int) {
+	w.m.Lock()
+	call, ok := w.pending[id]
+	if ok {
+		call.aborted.Store(true)
+		w.dropBlobs(call)
+	}
+	w.m.Unlock()
+	if ok {
+		call.cancel()
+	}
+}
+
+// cancelPending cancels every pending call. It is called when the document
+// that issued the calls goes away, so their results and any blobs not
+// fetched yet are discarded.
+func (w *webview) cancelPending() {
+	w.m.Lock()
+	pending := w.pending
+	w.pending = map[int]*pendingCall{}
+	w.blobs = map[string]*blob{}
+	callbacks := w.callbacks
+	w.callbacks = map[int]*JSResult{}
+	w.generation++
+	w.deliveries = 0
+	w.m.Unlock()
+	for _, call := range pending {
+		call.cancel()
+	}
+	for _, r := range callbacks {
+		r.settle(nil, errDocumentGone)
+	}
+}
+
+// documentLoading starts the document loaded from source by navigation id,
+// as reported by WebView2's ContentLoading event. The calls of the previous
+// document are cancelled, and the navigation is recorded so that a
+// Replayer does the same.
+func (w *webview) documentLoading(id uint64, source string) {
+	w.cancelPending()
+	w.m.Lock()
+	w.documentOrigin = originOf(source)
+	w.navigationID = id
+	w.m.Unlock()
+	w.recorder.record(TraceEntry{Kind: TraceNavigation, Source: source, Navigation: id})
+}
+
+// usesJSON reports whether messages are encoded as JSON rather than with a
+// binary codec.
+func (w *webview) usesJSON() bool { return w.codec.Name() == "json" }
+
+// unmarshalMessage decodes a message posted by the JavaScript runtime.
+// Binary codecs post their messages base64-encoded.
+func (w *webview) unmarshalMessage(msg string, d *rpcMessage) error {
+	if w.usesJSON() {
+		return json.Unmarshal([]byte(msg), d)
+	}
+	data, err := base64.StdEncoding.DecodeString(msg)
+	if err != nil {
+		return err
+	}
+	return w.codec.Unmarshal(data, d)
+}
+
+// message returns the message that calls window._rpc[op] with args, which
+// is posted in chunks if it is large. With JSON, args are marshaled like
+// results; binary codecs pass the encoded op and args to window._rpc.recv
+// instead.
+func (w *webview) message(op string, args ...interface{}) (message, error) {
+	return w.encodeMessage(op, args, nil)
+}
+
+// callMessage is like message for the messages that answer call.
+func (w *webview) callMessage(call *pendingCall, op string, args ...interface{}) (message, error) {
+	return w.encodeMessage(op, args, call)
+}
+
+func (w *webview) encodeMessage(op string, args []interface{}, call *pendingCall) (message, error) {
+	var m message
+	switch op {
+	case "resolve", "fail", "item":
+		m.call, _ = args[0].(int)
+	}
+	if !w.usesJSON() {
+		b, err := w.codec.Marshal(append([]interface{}{op}, args...))
+		if err != nil {
+			return m, err
+		}
+		data := base64.StdEncoding.EncodeToString(b)
+		if w.chunked(len(data)) {
+			m.payload = data
+		} else {
+			m.js = "window._rpc.recv(\"" + data + "\")"
+		}
+		return m, nil
+	}
+	encoded := make([]string, len(args))
+	size := 0
+	for i, arg := range args {
+		b, err := w.marshalResult(arg, call)
+		if err != nil {
+			return m, err
+		}
+		encoded[i] = string(b)
+		size += len(b)
+	}
+	if w.chunked(size) {
+		m.payload = "[" + jsString(op) + "," + strings.Join(encoded, ",") + "]"
+	} else {
+		m.js = "window._rpc." + op + "(" + strings.Join(encoded, ", ") + ")"
+	}
+	return m, nil
+}
+
+// respond settles the JavaScript promise of call id on the UI thread. The
+// result is dropped if the document that made the call is gone, or if the
+// call was already answered, e.g. because it timed out.
+func (w *webview) respond(call *pendingCall, callID int, res interface{}, err error) {
+	if call.answered.Swap(true) {
+		return
+	}
+	w.recorder.recordValue(TraceEntry{Kind: TraceResponse, ID: callID}, res, err)
+	if call.aborted.Load() {
+		call.stats.finish(err)
+		return
+	}
+	start := time.Now()
+	var m message
+	if err == nil {
+		m, err = w.callMessage(call, "resolve", callID, res)
+	}
+	if err != nil {
+		m = w.failMessage(callID, err)
+	}
+	call.stats.encoded(time.Since(start))
+	call.stats.finish(err)
+	w.send(call, m)
+}
+
+// failMessage returns the message that rejects the promise of call id with
+// err. Data that cannot be marshaled is left out rather than failing the
+// whole error.
+func (w *webview) failMessage(callID int, err error) message {
+	e := newJSError(err)
+	m, merr := w.message("fail", callID, e)
+	if merr != nil {
+		e.Data = nil
+		m, _ = w.message("fail", callID, e)
+	}
+	return m
+}
+
+// send delivers m on the UI thread unless the document that made call is
+// gone.
+func (w *webview) send(call *pendingCall, m message) {
+	w.evalIn(call.generation, m)
+}
+
+// evalIn delivers m on the UI thread if the current document is still the
+// one identified by generation.
+func (w *webview) evalIn(generation uint64, m message) {
+	w.Dispatch(func() {
+		w.m.Lock()
+		current := generation == w.generation
+		w.m.Unlock()
+		if current {
+			w.deliver(m)
+		}
+	})
+}
+
+func (w *webview) callbinding(bc *BindingCall, call *pendingCall) (interface{}, error) {
+	if bc.Codec == nil {
+		// Middleware may pass on calls it made up.
+		bc.Codec = w.codec
+	}
+	w.m.Lock()
+	b, ok := w.bindings[bc.Method]
+	w.m.Unlock()
+	if !ok {
+		switch bc.Method {
+		case bindingsMethod:
+			return w.Bindings(), nil
+		case storeWriteMethod, storeSyncMethod:
+			return w.storeCall(bc)
+		}
+		return nil, errUnknownBinding(bc.Method)
+	}
+
+	t := reflect.TypeOf(b.f)
+	isVariadic := t.IsVariadic()
+	// in are the types of the parameters decoded from the arguments; the
+	// others are filled in.
+	var in []reflect.Type
+	for i := 0; i < t.NumIn(); i++ {
+		if !isInjected(t, i) {
+			in = append(in, t.In(i))
+		}
+	}
+	if (isVariadic && len(bc.Params) < len(in)-1) || (!isVariadic && len(bc.Params) != len(in)) {
+		return nil, &paramsError{errors.New("function arguments mismatch")}
+	}
+	if b.schema != nil {
+		if err := validateParams(bc.Codec, b.schema, bc.Params); err != nil {
+			return nil, &paramsError{err}
+		}
+	}
+	start := time.Now()
+	params := make([]reflect.Value, len(bc.Params))
+	var invalid []FieldError
+	for i := range bc.Params {
+		var arg reflect.Value
+		if isVariadic && i >= len(in)-1 {
+			arg = reflect.New(in[len(in)-1].Elem())
+		} else {
+			arg = reflect.New(in[i])
+		}
+		if err := unmarshalParam(bc.Codec, bc.Params[i], arg); err != nil {
+			return nil, &paramsError{err}
+		}
+		w.bindFuncs(arg.Elem(), call.generation)
+		params[i] = arg.Elem()
+
+		v := validator{param: i}
+		v.validateValue(arg.Elem(), "")
+		invalid = append(invalid, v.errors...)
+	}
+	call.stats.decoded(time.Since(start))
+	if len(invalid) > 0 {
+		return nil, &paramsError{&ValidationError{Fields: invalid}}
+	}
+
+	args := make([]reflect.Value, 0, t.NumIn()+len(params))
+	next := 0
+	for i := 0; i < t.NumIn(); i++ {
+		switch {
+		case i == 0 && t.In(i) == contextType:
+			args = append(args, reflect.ValueOf(bc.Context))
+		case t.In(i) == callInfoType:
+			args = append(args, reflect.ValueOf(bc.CallInfo))
+		case isVariadic && i == t.NumIn()-1:
+			args = append(args, params[next:]...)
+		default:
+			args = append(args, params[next])
+			next++
+		}
+	}
+
+	res := reflect.ValueOf(b.f).Call(args)
+	switch len(res) {
+	case 0:
+		// No results from the function, just return nil
+		return nil, nil
+
+	case 1:
+		// One result may be a value, or an error
+		if res[0].Type().Implements(errorType) {
+			if res[0].Interface() != nil {
+				return nil, res[0].Interface().(error)
+			}
+			return nil, nil
+		}
+		return res[0].Interface(), nil
+
+	case 2:
+		// Two results: first one is value, second is error
+		if !res[1].Type().Implements(errorType) {
+			return nil, errors.New("second return value must be an error")
+		}
+		if res[1].Interface() == nil {
+			return res[0].Interface(), nil
+		}
+		return res[0].Interface(), res[1].Interface().(error)
+
+	default:
+		return nil, errors.New("unexpected number of return values")
+	}
+}
@@ -0,0 +1,862 @@
+(function() {
+	if (window._rpc && window._rpc.call) {
+		return;
+	}
+	var RPC = window._rpc = {nextSeq: 1};
+
+	// Functions passed to Go, by handle, until Go releases them.
+	var funcs = {};
+	var nextFunc = 1;
+
+	// Number of stream items Go may send before JavaScript consumed them.
+	var streamWindow = 16;
+
+	// Where large binary results are fetched from. Must match blobURL in
+	// blob.go.
+	var blobURL = "https://go-webview2.localhost/blob/";
+
+	var config = window._rpcConfig || {};
+
+	// codec encodes messages in the binary format selected by
+	// WebViewOptions.Codec. It is null for JSON.
+	var codec = config.codec && config.codec !== "json" ? window._rpcCodecs[config.codec] : null;
+
+	// post sends msg to Go. Functions in the arguments of calls are passed
+	// as handles Go decodes into a JSFunc; elsewhere, e.g. in results, Go
+	// could never release them, so they are left out like JSON.stringify
+	// does.
+	function post(msg, call) {
+		if (codec) {
+			window.external.invoke(toBase64(codec.encode(msg, call ? handle : null)));
+			return;
+		}
+		window.external.invoke(JSON.stringify(msg, call ? encodeCall : encode));
+	}
+
+	// handle replaces functions with a handle Go decodes into a JSFunc.
+	function handle(value) {
+		if (typeof value === "function") {
+			var id = nextFunc++;
+			funcs[id] = value;
+			return {$func: id};
+		}
+		return value;
+	}
+
+	// encode replaces binary data with the envelope Go decodes into byte
+	// slices.
+	function encode(key, value) {
+		if (value instanceof ArrayBuffer || ArrayBuffer.isView(value)) {
+			var bytes = value instanceof ArrayBuffer ? new Uint8Array(value) : new Uint8Array(value.buffer, value.byteOffset, value.byteLength);
+			return {$bytes: toBase64(bytes)};
+		}
+		return value;
+	}
+
+	// encodeCall is encode for the arguments of calls, which may include
+	// functions.
+	function encodeCall(key, value) {
+		return encode(key, handle(value));
+	}
+
+	function toBase64(bytes) {
+		if (bytes.toBase64) {
+			return bytes.toBase64();
+		}
+		var s = "";
+		for (var i = 0; i < bytes.length; i += 0x8000) {
+			s += String.fromCharCode.apply(null, bytes.subarray(i, i + 0x8000));
+		}
+		return btoa(s);
+	}
+
+	function fromBase64(s) {
+		if (Uint8Array.fromBase64) {
+			return Uint8Array.fromBase64(s);
+		}
+		var bin = atob(s);
+		var bytes = new Uint8Array(bin.length);
+		for (var i = 0; i < bin.length; i++) {
+			bytes[i] = bin.charCodeAt(i);
+		}
+		return bytes;
+	}
+
+	// decode replaces the binary envelopes in a value sent by Go with
+	// Uint8Arrays.
+	function decode(value) {
+		if (value === null || typeof value !== "object") {
+			return value;
+		}
+		if (Array.isArray(value)) {
+			for (var i = 0; i < value.length; i++) {
+				value[i] = decode(value[i]);
+			}
+			return value;
+		}
+		var keys = Object.keys(value);
+		if (keys.length === 1 && keys[0] === "$bytes" && typeof value.$bytes === "string") {
+			return fromBase64(value.$bytes);
+		}
+		for (var j = 0; j < keys.length; j++) {
+			value[keys[j]] = decode(value[keys[j]]);
+		}
+		return value;
+	}
+
+	// receive decodes a result or stream item. Large binary values are
+	// fetched separately, in which case a promise is returned.
+	function receive(value) {
+		if (value !== null && typeof value === "object" && typeof value.$blob === "string") {
+			return fetch(blobURL + value.$blob).then(function(res) {
+				if (!res.ok) {
+					throw new Error("fetching binary result failed: " + res.status);
+				}
+				return res.arrayBuffer();
+			}).then(function(buf) {
+				return new Uint8Array(buf);
+			});
+		}
+		return decode(value);
+	}
+
+	function isAbortSignal(v) {
+		return typeof AbortSignal !== "undefined" && v instanceof AbortSignal;
+	}
+
+	function abortReason(signal) {
+		return signal.reason !== undefined ? signal.reason : new DOMException("The operation was aborted.", "AbortError");
+	}
+
+	// timeoutError is the error a call is rejected with when its timeout
+	// passed. It matches the error of a timeout set in Go.
+	function timeoutError(name, timeout) {
+		return new GoError({message: "call of " + name + " timed out after " + timeout + "ms", code: "timeout", status: 504});
+	}
+
+	// callSignal returns the AbortSignal of a call, passed either as the last
+	// argument, which is removed from params, or in options.
+	function callSignal(params, options) {
+		if (params.length > 0 && isAbortSignal(params[params.length - 1])) {
+			return params.pop();
+		}
+		return options.signal || null;
+	}
+
+	// traceOf returns the trace ID sent with a call of name: the trace
+	// option, or the result of go.trace if the page set it.
+	function traceOf(name, options) {
+		var trace = options.trace;
+		if (trace === undefined && typeof go.trace === "function") {
+			trace = go.trace(name);
+		}
+		return trace ? String(trace) : undefined;
+	}
+
+	RPC.call = function(name, args, options) {
+		options = options || {};
+		var params = Array.prototype.slice.call(args);
+		var signal = callSignal(params, options);
+		if (signal && signal.aborted) {
+			return Promise.reject(abortReason(signal));
+		}
+		var seq = RPC.nextSeq++;
+		var promise = new Promise(function(resolve, reject) {
+			RPC[seq] = {
+				resolve: resolve,
+				reject: reject,
+			};
+		});
+		if (options.timeout > 0) {
+			var timer = setTimeout(function() {
+				if (RPC[seq]) {
+					post({id: seq, cancel: true});
+					RPC.reject(seq, timeoutError(name, options.timeout));
+				}
+			}, options.timeout);
+			var clear = function() {
+				clearTimeout(timer);
+			};
+			promise.then(clear, clear);
+		}
+		if (signal) {
+			var onAbort = function() {
+				if (RPC[seq]) {
+					post({id: seq, cancel: true});
+					RPC.reject(seq, abortReason(signal));
+				}
+			};
+			var cleanup = function() {
+				signal.removeEventListener("abort", onAbort);
+			};
+			signal.addEventListener("abort", onAbort);
+			promise.then(cleanup, cleanup);
+		}
+		post({
+			id: seq,
+			method: name,
+			params: params,
+			timeout: options.timeout,
+			trace: traceOf(name, options),
+		}, true);
+		return promise;
+	};
+
+	RPC.resolve = function(seq, value) {
+		var p = RPC[seq];
+		if (p) {
+			RPC[seq] = undefined;
+			p.resolve(receive(value));
+		}
+	};
+
+	RPC.reject = function(seq, reason) {
+		var p = RPC[seq];
+		if (p) {
+			RPC[seq] = undefined;
+			p.reject(reason);
+		}
+	};
+
+	// GoError is the error a promise is rejected with when the bound Go
+	// function returned an error.
+	class GoError extends Error {
+		constructor(e) {
+			super(e.message);
+			this.name = e.name || "GoError";
+			this.code = e.code;
+			this.status = e.status;
+			this.data = e.data;
+			this.chain = e.chain || [];
+		}
+	}
+
+	// GoPanic is the error a promise is rejected with when the bound Go
+	// function panicked.
+	class GoPanic extends GoError {}
+
+	var go = window.go = window.go || {};
+	go.GoError = GoError;
+	go.GoPanic = GoPanic;
+
+	// go.__bindings resolves to the functions bound in Go, as returned by
+	// Bindings.
+	go.__bindings = function() {
+		return RPC.call("__bindings", []);
+	};
+
+	// Stores shared with Go, by name. See Store in store.go.
+	var stores = {};
+
+	function storeState(name) {
+		if (!Object.prototype.hasOwnProperty.call(stores, name)) {
+			stores[name] = {name: name, version: 0, value: undefined, subs: [], handle: null};
+		}
+		return stores[name];
+	}
+
+	function storeNotify(st) {
+		st.subs.slice().forEach(function(cb) {
+			try {
+				cb(st.value, st.version);
+			} catch (e) {
+				console.error(e);
+			}
+		});
+	}
+
+	function storeReplace(st, version, value) {
+		if (version > st.version) {
+			st.version = version;
+			st.value = value;
+			storeNotify(st);
+		}
+	}
+
+	// storeSync fetches the current snapshot of a store if the one the
+	// document has is outdated.
+	function storeSync(st) {
+		RPC.call("__storeSync", [st.name, st.version]).then(function(res) {
+			if (res) {
+				storeReplace(st, res[0], res[1]);
+			}
+		}, function(e) {
+			console.error(e);
+		});
+	}
+
+	function clone(value) {
+		return value === undefined ? undefined : JSON.parse(JSON.stringify(value));
+	}
+
+	function isObject(v) {
+		return v !== null && typeof v === "object" && !Array.isArray(v);
+	}
+
+	function escapePointer(s) {
+		return s.replace(/~/g, "~0").replace(/\//g, "~1");
+	}
+
+	// diff appends the JSON Patch operations that turn a into b to ops, like
+	// diffJSON in jsonpatch.go.
+	function diff(ops, path, a, b) {
+		if (isObject(a) && isObject(b)) {
+			Object.keys(a).sort().forEach(function(k) {
+				if (!Object.prototype.hasOwnProperty.call(b, k)) {
+					ops.push({op: "remove", path: path + "/" + escapePointer(k)});
+				}
+			});
+			Object.keys(b).sort().forEach(function(k) {
+				if (Object.prototype.hasOwnProperty.call(a, k)) {
+					diff(ops, path + "/" + escapePointer(k), a[k], b[k]);
+				} else {
+					ops.push({op: "add", path: path + "/" + escapePointer(k), value: b[k]});
+				}
+			});
+		} else if (Array.isArray(a) && Array.isArray(b)) {
+			var n = Math.min(a.length, b.length);
+			for (var i = 0; i < n; i++) {
+				diff(ops, path + "/" + i, a[i], b[i]);
+			}
+			for (var j = a.length - 1; j >= n; j--) {
+				ops.push({op: "remove", path: path + "/" + j});
+			}
+			for (var k = n; k < b.length; k++) {
+				ops.push({op: "add", path: path + "/" + k, value: b[k]});
+			}
+		} else if (JSON.stringify(a) !== JSON.stringify(b)) {
+			ops.push({op: "replace", path: path, value: b});
+		}
+		return ops;
+	}
+
+	function applyPatch(doc, ops) {
+		doc = clone(doc);
+		for (var i = 0; i < ops.length; i++) {
+			doc = applyOp(doc, ops[i]);
+		}
+		return doc;
+	}
+
+	function applyOp(doc, op) {
+		var value = op.value === undefined ? null : clone(op.value);
+		if (op.path === "") {
+			return op.op === "remove" ? null : value;
+		}
+		var tokens = op.path.slice(1).split("/").map(function(t) {
+			return t.replace(/~1/g, "/").replace(/~0/g, "~");
+		});
+		var parent = doc;
+		for (var i = 0; i < tokens.length - 1; i++) {
+			if (parent === null || typeof parent !== "object" || !Object.prototype.hasOwnProperty.call(parent, tokens[i])) {
+				throw new Error("no such path: " + op.path);
+			}
+			parent = parent[tokens[i]];
+		}
+		if (parent === null || typeof parent !== "object") {
+			throw new Error("no such path: " + op.path);
+		}
+		var last = tokens[tokens.length - 1];
+		if (Array.isArray(parent)) {
+			var index = last === "-" ? parent.length : Number(last);
+			if (op.op === "add") {
+				parent.splice(index, 0, value);
+			} else if (op.op === "remove") {
+				parent.splice(index, 1);
+			} else {
+				parent[index] = value;
+			}
+		} else if (op.op === "remove") {
+			delete parent[last];
+		} else {
+			Object.defineProperty(parent, last, {value: value, writable: true, enumerable: true, configurable: true});
+		}
+		return doc;
+	}
+
+	// storeInit hydrates a store from the init script of a new document, or
+	// for the current document when the store is created.
+	RPC.storeInit = function(name) {
+		storeSync(storeState(name));
+	};
+
+	RPC.storePatch = function(name, version, ops) {
+		var st = storeState(name);
+		if (version <= st.version) {
+			return;
+		}
+		if (version !== st.version + 1) {
+			storeSync(st);
+			return;
+		}
+		try {
+			st.value = applyPatch(st.value, ops);
+		} catch (e) {
+			console.error(e);
+			storeSync(st);
+			return;
+		}
+		st.version = version;
+		storeNotify(st);
+	};
+
+	// go.store returns the store created in Go with name. Its value changes
+	// when Go changes it, and set and update write to it.
+	go.store = function(name) {
+		var st = storeState(name);
+		if (!st.handle) {
+			st.handle = {
+				get value() {
+					return st.value;
+				},
+				get version() {
+					return st.version;
+				},
+				// subscribe calls cb with the value and version now, if the store
+				// is hydrated, and after every change. It returns a function that
+				// unsubscribes cb.
+				subscribe: function(cb) {
+					st.subs.push(cb);
+					if (st.version > 0) {
+						cb(st.value, st.version);
+					}
+					return function() {
+						st.subs = st.subs.filter(function(x) {
+							return x !== cb;
+						});
+					};
+				},
+				// set writes value to the store. It resolves to the new version
+				// once Go accepted it.
+				set: function(value) {
+					var ops = diff([], "", st.value, clone(value));
+					if (ops.length === 0) {
+						return Promise.resolve(st.version);
+					}
+					return RPC.call("__storeWrite", [name, st.version, ops]);
+				},
+				// update sets the store to what fn returns for a copy of the
+				// value, or to the copy if fn modified it in place.
+				update: function(fn) {
+					var copy = clone(st.value);
+					var res = fn(copy);
+					return this.set(res === undefined ? copy : res);
+				},
+			};
+		}
+		return st.handle;
+	};
+
+	// Listeners of events emitted by Go, in the order they were added.
+	var listeners = [];
+
+	// matchTopic implements the topic patterns of matchTopic in events.go.
+	function matchTopic(pattern, topic) {
+		var p = pattern.split(".");
+		var t = topic.split(".");
+		for (var i = 0; i < p.length; i++) {
+			if (p[i] === "**" && i === p.length - 1) {
+				return true;
+			}
+			if (i >= t.length || (p[i] !== "*" && p[i] !== t[i])) {
+				return false;
+			}
+		}
+		return p.length === t.length;
+	}
+
+	function listen(pattern, cb, once) {
+		var l = {pattern: pattern, cb: cb, once: once};
+		listeners.push(l);
+		return function() {
+			listeners = listeners.filter(function(x) {
+				return x !== l;
+			});
+		};
+	}
+
+	// go.on calls cb with the payload and topic of every event Go emits on a
+	// topic matching pattern. It returns a function that removes cb.
+	go.on = function(pattern, cb) {
+		return listen(pattern, cb, false);
+	};
+
+	go.once = function(pattern, cb) {
+		return listen(pattern, cb, true);
+	};
+
+	// go.off removes cb, or every listener, from pattern.
+	go.off = function(pattern, cb) {
+		listeners = listeners.filter(function(l) {
+			return l.pattern !== pattern || (cb !== undefined && l.cb !== cb);
+		});
+	};
+
+	// go.emit sends an event to the Go handlers of topic.
+	go.emit = function(topic, payload) {
+		post({event: topic, payload: payload});
+	};
+
+	RPC.emit = function(topic, payload) {
+		Promise.resolve(receive(payload)).then(function(payload) {
+			var matched = listeners.filter(function(l) {
+				return matchTopic(l.pattern, topic);
+			});
+			listeners = listeners.filter(function(l) {
+				return !(l.once && matched.indexOf(l) >= 0);
+			});
+			matched.forEach(function(l) {
+				try {
+					l.cb(payload, topic);
+				} catch (e) {
+					console.error(e);
+				}
+			});
+		});
+	};
+
+	RPC.fail = function(seq, e) {
+		RPC.reject(seq, e.name === "GoPanic" ? new GoPanic(e) : new GoError(e));
+	};
+
+	function jsError(e) {
+		if (e instanceof Error) {
+			return {name: e.name, message: e.message, stack: e.stack};
+		}
+		return {name: "Error", message: String(e)};
+	}
+
+	// settle posts the result of promise to Go as the result of callback.
+	function settle(callback, promise) {
+		promise.then(function(result) {
+			post({callback: callback, result: result});
+		}, function(e) {
+			post({callback: callback, error: jsError(e)});
+		});
+	}
+
+	// invoke calls a function passed to Go and posts its result.
+	RPC.invoke = function(id, callback) {
+		var args = Array.prototype.slice.call(arguments, 2);
+		var fn = funcs[id];
+		settle(callback, Promise.all(args.map(receive)).then(function(args) {
+			if (!fn) {
+				throw new Error("function was released");
+			}
+			return fn.apply(null, args);
+		}));
+	};
+
+	// evaluate evaluates the source of a script of EvalResult in the global
+	// scope. The result is returned to ExecuteScript, encoded like the
+	// results of calls, or posted later if it is a promise.
+	RPC.evaluate = function(callback, src) {
+		var value;
+		try {
+			value = (0, eval)(src);
+		} catch (e) {
+			return {error: jsError(e)};
+		}
+		if (value !== null && (typeof value === "object" || typeof value === "function") && typeof value.then === "function") {
+			settle(callback, Promise.resolve(value));
+			return {pending: true};
+		}
+		var json = JSON.stringify(value, encode);
+		return {value: json === undefined ? "null" : json};
+	};
+
+	// recv calls the function of RPC named by the first element of a
+	// message encoded with a binary codec, with the remaining elements as
+	// arguments.
+	RPC.recv = function(data) {
+		var msg = codec.decode(fromBase64(data));
+		RPC[msg[0]].apply(null, msg.slice(1));
+	};
+
+	// Deliveries of Go are numbered when large messages may be posted in
+	// chunks, because web messages may overtake scripts or be overtaken by
+	// them. ordered runs them in order. The first delivery a document sees
+	// sets the start, as earlier ones may have gone to the previous document.
+	var nextDelivery = 0;
+	var deliveries = {};
+
+	RPC.ordered = function(seq, fn) {
+		if (nextDelivery === 0) {
+			nextDelivery = seq;
+		}
+		if (seq < nextDelivery) {
+			fn();
+			return;
+		}
+		deliveries[seq] = fn;
+		while (deliveries[nextDelivery]) {
+			var next = deliveries[nextDelivery];
+			delete deliveries[nextDelivery];
+			nextDelivery++;
+			try {
+				next();
+			} catch (e) {
+				console.error(e);
+			}
+		}
+	};
+
+	// abandon replaces delivery seq when Go failed to post its chunks. It
+	// rejects call, the call the message belongs to, if any.
+	RPC.abandon = function(seq, call) {
+		delete transfers[seq];
+		abandoned[seq] = true;
+		failCall(call, new Error("a message from Go could not be delivered"));
+	};
+
+	// failCall rejects call after the message settling or feeding it was
+	// lost, and aborts it in Go if it is a stream.
+	function failCall(call, e) {
+		if (call && RPC[call]) {
+			if (RPC[call].item) {
+				RPC[call].reject(e);
+				RPC[call] = undefined;
+				post({id: call, cancel: true});
+			} else {
+				RPC.reject(call, e);
+			}
+		} else {
+			console.error(e);
+		}
+	}
+
+	// Messages being received in chunks, by delivery, and the deliveries
+	// that were abandoned. See chunk.go.
+	var transfers = {};
+	var abandoned = {};
+	var crcTable = null;
+
+	function crc32(s) {
+		if (!crcTable) {
+			crcTable = new Int32Array(256);
+			for (var n = 0; n < 256; n++) {
+				var c = n;
+				for (var k = 0; k < 8; k++) {
+					c = c & 1 ? 0xEDB88320 ^ (c >>> 1) : c >>> 1;
+				}
+				crcTable[n] = c;
+			}
+		}
+		var bytes = new TextEncoder().encode(s);
+		var crc = -1;
+		for (var i = 0; i < bytes.length; i++) {
+			crc = crcTable[(crc ^ bytes[i]) & 0xFF] ^ (crc >>> 8);
+		}
+		return (crc ^ -1) >>> 0;
+	}
+
+	function receiveChunk(c) {
+		if (nextDelivery === 0) {
+			nextDelivery = c.seq;
+		}
+		if (abandoned[c.seq]) {
+			return;
+		}
+		var t = transfers[c.seq];
+		if (!t) {
+			t = transfers[c.seq] = {parts: [], received: 0, failed: false};
+		}
+		if (t.failed) {
+			return;
+		}
+		if (crc32(c.data) !== c.crc || c.index >= c.count) {
+			t.failed = true;
+			t.parts = [];
+			RPC.ordered(c.seq, function() {
+				failCall(c.call, new Error("a message from Go failed its integrity check"));
+			});
+			return;
+		}
+		if (t.parts[c.index] === undefined) {
+			t.parts[c.index] = c.data;
+			t.received++;
+		}
+		if (t.received < c.count) {
+			return;
+		}
+		delete transfers[c.seq];
+		var payload = t.parts.join("");
+		RPC.ordered(c.seq, function() {
+			var msg = codec ? codec.decode(fromBase64(payload)) : JSON.parse(payload);
+			RPC[msg[0]].apply(null, msg.slice(1));
+		});
+	}
+
+	if (window.chrome && window.chrome.webview) {
+		window.chrome.webview.addEventListener("message", function(e) {
+			if (e.data !== null && typeof e.data === "object" && e.data.$rpcChunk) {
+				receiveChunk(e.data.$rpcChunk);
+			}
+		});
+	}
+
+	RPC.release = function(id) {
+		delete funcs[id];
+	};
+
+	RPC.item = function(seq, value) {
+		var p = RPC[seq];
+		if (p && p.item) {
+			p.item(receive(value));
+		}
+	};
+
+	RPC.stream = function(name, args, options) {
+		options = options || {};
+		var params = Array.prototype.slice.call(args);
+		var signal = callSignal(params, options);
+		var seq = RPC.nextSeq++;
+		var items = [];
+		var waiters = [];
+		var finished = false;
+		var failure = null;
+		var consumed = 0;
+
+		function flush() {
+			while (waiters.length > 0 && (items.length > 0 || finished)) {
+				var waiter = waiters.shift();
+				if (items.length > 0) {
+					waiter.resolve(Promise.resolve(items.shift()).then(function(value) {
+						return {value: value, done: false};
+					}));
+					if (!finished && ++consumed >= streamWindow / 2) {
+						post({id: seq, credit: consumed});
+						consumed = 0;
+					}
+				} else if (failure !== null) {
+					waiter.reject(failure);
+					failure = null;
+				} else {
+					waiter.resolve({value: undefined, done: true});
+				}
+			}
+		}
+
+		function stop(reason) {
+			if (finished) {
+				return;
+			}
+			finished = true;
+			items = [];
+			failure = reason === undefined ? null : reason;
+			if (RPC[seq]) {
+				RPC[seq] = undefined;
+				post({id: seq, cancel: true});
+			}
+			flush();
+		}
+
+		RPC[seq] = {
+			item: function(value) {
+				items.push(value);
+				flush();
+			},
+			resolve: function() {
+				finished = true;
+				flush();
+			},
+			reject: function(reason) {
+				finished = true;
+				failure = reason;
+				flush();
+			},
+		};
+		if (signal) {
+			if (signal.aborted) {
+				RPC[seq] = undefined;
+				finished = true;
+				failure = abortReason(signal);
+				return iterator();
+			}
+			signal.addEventListener("abort", function() {
+				stop(abortReason(signal));
+			});
+		}
+		if (options.timeout > 0) {
+			setTimeout(function() {
+				stop(timeoutError(name, options.timeout));
+			}, options.timeout);
+		}
+		post({
+			id: seq,
+			method: name,
+			params: params,
+			credit: streamWindow,
+			timeout: options.timeout,
+			trace: traceOf(name, options),
+		}, true);
+
+		function iterator() {
+			var it = {
+				next: function() {
+					return new Promise(function(resolve, reject) {
+						waiters.push({resolve: resolve, reject: reject});
+						flush();
+					});
+				},
+				return: function(value) {
+					stop();
+					return Promise.resolve({value: value, done: true});
+				},
+			};
+			it[Symbol.asyncIterator] = function() {
+				return it;
+			};
+			return it;
+		}
+		return iterator();
+	};
+
+	RPC.bind = function(name, stream, path) {
+		var target = window;
+		path = path || [name];
+		for (var i = 0; i < path.length - 1; i++) {
+			if (typeof target[path[i]] !== "object" || target[path[i]] === null) {
+				target[path[i]] = {};
+			}
+			target = target[path[i]];
+		}
+		target[path[path.length - 1]] = bound(name, stream, undefined);
+	};
+
+	// bound returns the function installed for a binding. Its withTimeout
+	// and withOptions methods return variants that call it with options,
+	// which may set a timeout in milliseconds, an AbortSignal and a trace
+	// ID.
+	function bound(name, stream, options) {
+		var fn = function() {
+			return stream ? RPC.stream(name, arguments, options) : RPC.call(name, arguments, options);
+		};
+		fn.withOptions = function(options) {
+			return bound(name, stream, options);
+		};
+		fn.withTimeout = function(timeout) {
+			return bound(name, stream, Object.assign({}, options, {timeout: timeout}));
+		};
+		return fn;
+	}
+
+	RPC.unbind = function(path) {
+		var objects = [window];
+		for (var i = 0; i < path.length - 1; i++) {
+			var next = objects[i][path[i]];
+			if (typeof next !== "object" || next === null) {
+				return;
+			}
+			objects.push(next);
+		}
+		delete objects[path.length - 1][path[path.length - 1]];
+		// Remove namespace objects that became empty.
+		for (var j = path.length - 2; j >= 0; j--) {
+			if (Object.keys(objects[j + 1]).length > 0) {
+				break;
+			}
+			delete objects[j][path[j]];
+		}
+	};
+})();
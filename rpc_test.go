@@ -0,0 +1,154 @@
+package webview2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCall(t *testing.T) {
+	for _, codec := range []Codec{JSONCodec, MessagePackCodec, CBORCodec} {
+		t.Run(codec.Name(), func(t *testing.T) {
+			f := NewFake(WebViewOptions{Codec: codec})
+			type user struct {
+				Name string `json:"name"`
+				Age  int    `json:"age"`
+			}
+			if err := f.Bind("greet", func(u user) string { return "hello " + u.Name }); err != nil {
+				t.Fatal(err)
+			}
+			if err := f.Bind("fail", func() error {
+				return &Error{Code: "denied", Status: 403, Message: "no"}
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			res, err := f.Call(context.Background(), "greet", user{Name: "ada", Age: 36})
+			if err != nil || string(res) != `"hello ada"` {
+				t.Errorf("greet = %s, %v", res, err)
+			}
+
+			_, err = f.Call(context.Background(), "fail")
+			var e *Error
+			if !errors.As(err, &e) || e.Code != "denied" || e.Status != 403 || e.Message != "no" {
+				t.Errorf("fail = %v, want the error of the function", err)
+			}
+
+			_, err = f.Call(context.Background(), "missing")
+			if !errors.As(err, &e) || e.Code != "not_found" {
+				t.Errorf("missing = %v, want not_found", err)
+			}
+
+			_, err = f.Call(context.Background(), "greet", 1, 2)
+			if err == nil || err.Error() != "function arguments mismatch" {
+				t.Errorf("greet(1, 2) = %v, want an argument mismatch", err)
+			}
+		})
+	}
+}
+
+func TestStream(t *testing.T) {
+	for _, codec := range []Codec{JSONCodec, MessagePackCodec, CBORCodec} {
+		f := NewFake(WebViewOptions{Codec: codec})
+		if err := f.Bind("count", func(n int) <-chan int {
+			c := make(chan int, n)
+			for i := 1; i <= n; i++ {
+				c <- i
+			}
+			close(c)
+			return c
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if err := f.Bind("letters", func() func(yield func(string) bool) {
+			return func(yield func(string) bool) {
+				_ = yield("a") && yield("b")
+			}
+		}); err != nil {
+			t.Fatal(err)
+		}
+		res, err := f.Call(context.Background(), "count", 3)
+		if err != nil || string(res) != `[1,2,3]` {
+			t.Errorf("%s: count = %s, %v", codec.Name(), res, err)
+		}
+		res, err = f.Call(context.Background(), "letters")
+		if err != nil || string(res) != `["a","b"]` {
+			t.Errorf("%s: letters = %s, %v", codec.Name(), res, err)
+		}
+	}
+}
+
+func TestTimeoutAnsweredOnce(t *testing.T) {
+	f := NewFake(WebViewOptions{BindingExecution: BindingExecutionGoroutine})
+	release := make(chan struct{})
+	returned := make(chan struct{})
+	if err := f.BindWithOptions("slow", func() int {
+		defer close(returned)
+		<-release
+		return 1
+	}, BindOptions{Timeout: 10 * time.Millisecond}); err != nil {
+		t.Fatal(err)
+	}
+	_, err := f.Call(context.Background(), "slow")
+	var e *Error
+	if !errors.As(err, &e) || e.Code != "timeout" {
+		t.Fatalf("slow = %v, want a timeout", err)
+	}
+	close(release)
+	<-returned
+	// The late result is dropped on the executor's goroutine; give it the
+	// chance to be recorded if it were not.
+	time.Sleep(10 * time.Millisecond)
+
+	var responses []json.RawMessage
+	for _, e := range f.Trace() {
+		if e.Kind == TraceResponse {
+			responses = append(responses, e.Error)
+		}
+	}
+	if len(responses) != 1 {
+		t.Errorf("got %d responses, want 1", len(responses))
+	}
+	if m := f.Metrics().Bindings[0]; m.Calls != 1 || m.Errors != 1 || m.InFlight != 0 {
+		t.Errorf("metrics = %+v, want one failed call", m)
+	}
+}
+
+// TestCallCancel checks that aborting a call from JavaScript, e.g. with an
+// AbortSignal, and leaving the document both cancel its context.
+func TestCallCancel(t *testing.T) {
+	for _, exec := range []BindingExecution{BindingExecutionGoroutine, BindingExecutionPool, BindingExecutionSerial} {
+		f := NewFake(WebViewOptions{BindingExecution: exec})
+		started := make(chan struct{})
+		cancelled := make(chan error)
+		if err := f.Bind("wait", func(ctx context.Context) error {
+			started <- struct{}{}
+			<-ctx.Done()
+			cancelled <- ctx.Err()
+			return ctx.Err()
+		}); err != nil {
+			t.Fatal(err)
+		}
+		for _, leave := range []struct {
+			name string
+			do   func()
+		}{
+			{"abort", func() { f.Post(`{"id": 1, "cancel": true}`) }},
+			{"navigation", func() { f.Navigate("https://app.example/") }},
+		} {
+			f.Post(`{"id": 1, "method": "wait", "params": []}`)
+			<-started
+			leave.do()
+			select {
+			case err := <-cancelled:
+				if err != context.Canceled {
+					t.Errorf("executor %d, %s: context error %v", exec, leave.name, err)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("executor %d, %s: context not cancelled", exec, leave.name)
+			}
+		}
+	}
+}
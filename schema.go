@@ -0,0 +1,273 @@
+package webview2
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// paramsSchema is a JSON Schema describing the array of arguments of a
+// bound function. Only the keywords checked by validateSchema are
+// supported; others are ignored.
+type paramsSchema struct {
+	root     map[string]interface{}
+	patterns map[string]*regexp.Regexp
+
+	// args holds the keywords of root that apply to the array of arguments
+	// as a whole, i.e. all but prefixItems and items, which are applied to
+	// each argument by param.
+	args map[string]interface{}
+}
+
+func parseParamsSchema(data json.RawMessage) (*paramsSchema, error) {
+	var root interface{}
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("invalid schema: %v", err)
+	}
+	m, ok := root.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("invalid schema: not an object")
+	}
+	s := &paramsSchema{root: m, patterns: map[string]*regexp.Regexp{}, args: map[string]interface{}{}}
+	if err := s.compile(m); err != nil {
+		return nil, fmt.Errorf("invalid schema: %v", err)
+	}
+	for k, v := range m {
+		if k != "prefixItems" && k != "items" {
+			s.args[k] = v
+		}
+	}
+	return s, nil
+}
+
+// compile compiles the patterns in schema.
+func (s *paramsSchema) compile(schema interface{}) error {
+	switch schema := schema.(type) {
+	case map[string]interface{}:
+		if p, ok := schema["pattern"].(string); ok {
+			re, err := regexp.Compile(p)
+			if err != nil {
+				return err
+			}
+			s.patterns[p] = re
+		}
+		for _, sub := range schema {
+			if err := s.compile(sub); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, sub := range schema {
+			if err := s.compile(sub); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// param returns the schema of argument i, given by prefixItems or items.
+func (s *paramsSchema) param(i int) map[string]interface{} {
+	if prefix, ok := s.root["prefixItems"].([]interface{}); ok && i < len(prefix) {
+		m, _ := prefix[i].(map[string]interface{})
+		return m
+	}
+	m, _ := s.root["items"].(map[string]interface{})
+	return m
+}
+
+// validateSchema checks value, the JSON value at path, against schema. The
+// supported keywords are type, enum, const, minLength, maxLength, pattern,
+// minimum, maximum, exclusiveMinimum, exclusiveMaximum, multipleOf,
+// properties, required, additionalProperties, items, prefixItems, minItems
+// and maxItems.
+func (v *validator) validateSchema(s *paramsSchema, schema map[string]interface{}, value interface{}, path string) {
+	if schema == nil {
+		return
+	}
+	if t, ok := schema["type"]; ok && !matchesType(t, value) {
+		v.fail(path, "type", "must be of type %s", typeNames(t))
+		return
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			if reflect.DeepEqual(e, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.fail(path, "enum", "must be one of %s", jsonList(enum))
+		}
+	}
+	if c, ok := schema["const"]; ok && !reflect.DeepEqual(c, value) {
+		v.fail(path, "const", "must be %s", jsonList([]interface{}{c}))
+	}
+
+	switch value := value.(type) {
+	case string:
+		n := float64(utf8.RuneCountInString(value))
+		if min, ok := number(schema, "minLength"); ok && n < min {
+			v.fail(path, "minLength", "must be at least %v characters long", min)
+		}
+		if max, ok := number(schema, "maxLength"); ok && n > max {
+			v.fail(path, "maxLength", "must be at most %v characters long", max)
+		}
+		if p, ok := schema["pattern"].(string); ok && !s.patterns[p].MatchString(value) {
+			v.fail(path, "pattern", "must match %s", p)
+		}
+	case float64:
+		if min, ok := number(schema, "minimum"); ok && value < min {
+			v.fail(path, "minimum", "must be at least %v", min)
+		}
+		if max, ok := number(schema, "maximum"); ok && value > max {
+			v.fail(path, "maximum", "must be at most %v", max)
+		}
+		if min, ok := number(schema, "exclusiveMinimum"); ok && value <= min {
+			v.fail(path, "exclusiveMinimum", "must be greater than %v", min)
+		}
+		if max, ok := number(schema, "exclusiveMaximum"); ok && value >= max {
+			v.fail(path, "exclusiveMaximum", "must be less than %v", max)
+		}
+		if m, ok := number(schema, "multipleOf"); ok && m > 0 {
+			if q := value / m; q != math.Trunc(q) {
+				v.fail(path, "multipleOf", "must be a multiple of %v", m)
+			}
+		}
+	case map[string]interface{}:
+		props, _ := schema["properties"].(map[string]interface{})
+		if required, ok := schema["required"].([]interface{}); ok {
+			for _, r := range required {
+				if name, ok := r.(string); ok {
+					if _, present := value[name]; !present {
+						v.fail(joinPath(path, name), "required", "is required")
+					}
+				}
+			}
+		}
+		names := make([]string, 0, len(value))
+		for name := range value {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if sub, ok := props[name].(map[string]interface{}); ok {
+				v.validateSchema(s, sub, value[name], joinPath(path, name))
+				continue
+			}
+			switch additional := schema["additionalProperties"].(type) {
+			case bool:
+				if !additional {
+					v.fail(joinPath(path, name), "additionalProperties", "is not allowed")
+				}
+			case map[string]interface{}:
+				v.validateSchema(s, additional, value[name], joinPath(path, name))
+			}
+		}
+	case []interface{}:
+		n := float64(len(value))
+		if min, ok := number(schema, "minItems"); ok && n < min {
+			v.fail(path, "minItems", "must have at least %v items", min)
+		}
+		if max, ok := number(schema, "maxItems"); ok && n > max {
+			v.fail(path, "maxItems", "must have at most %v items", max)
+		}
+		prefix, _ := schema["prefixItems"].([]interface{})
+		items, _ := schema["items"].(map[string]interface{})
+		for i, item := range value {
+			sub := items
+			if i < len(prefix) {
+				sub, _ = prefix[i].(map[string]interface{})
+			}
+			v.validateSchema(s, sub, item, path+"["+strconv.Itoa(i)+"]")
+		}
+	}
+}
+
+func number(schema map[string]interface{}, keyword string) (float64, bool) {
+	n, ok := schema[keyword].(float64)
+	return n, ok
+}
+
+func matchesType(t interface{}, value interface{}) bool {
+	switch t := t.(type) {
+	case string:
+		return matchesTypeName(t, value)
+	case []interface{}:
+		for _, name := range t {
+			if s, ok := name.(string); ok && matchesTypeName(s, value) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func matchesTypeName(name string, value interface{}) bool {
+	switch value := value.(type) {
+	case nil:
+		return name == "null"
+	case bool:
+		return name == "boolean"
+	case float64:
+		return name == "number" || name == "integer" && value == math.Trunc(value)
+	case string:
+		return name == "string"
+	case []interface{}:
+		return name == "array"
+	case map[string]interface{}:
+		return name == "object"
+	}
+	return false
+}
+
+func typeNames(t interface{}) string {
+	if names, ok := t.([]interface{}); ok {
+		s := make([]string, len(names))
+		for i, n := range names {
+			s[i] = fmt.Sprint(n)
+		}
+		return strings.Join(s, " or ")
+	}
+	return fmt.Sprint(t)
+}
+
+func jsonList(values []interface{}) string {
+	s := make([]string, len(values))
+	for i, v := range values {
+		b, _ := json.Marshal(v)
+		s[i] = string(b)
+	}
+	return strings.Join(s, ", ")
+}
+
+// validateParams checks the arguments of a call, encoded with c, against s.
+func validateParams(c Codec, s *paramsSchema, params []json.RawMessage) error {
+	values := make([]interface{}, len(params))
+	for i, p := range params {
+		if err := c.Unmarshal(p, &values[i]); err != nil {
+			return err
+		}
+	}
+	args := validator{param: -1}
+	args.validateSchema(s, s.args, values, "")
+	fields := args.errors
+	for i, value := range values {
+		v := validator{param: i}
+		v.validateSchema(s, s.param(i), value, "")
+		fields = append(fields, v.errors...)
+	}
+	if len(fields) > 0 {
+		return &ValidationError{Fields: fields}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package webview2
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParamsSchema(t *testing.T) {
+	s, err := parseParamsSchema(json.RawMessage(`{
+		"maxItems": 3,
+		"prefixItems": [
+			{"type": "string", "minLength": 1, "maxLength": 5, "pattern": "^[a-z]+$"},
+			{"type": "integer", "minimum": 1, "exclusiveMaximum": 10, "multipleOf": 2}
+		],
+		"items": {
+			"type": "object",
+			"required": ["id"],
+			"properties": {
+				"id": {"enum": ["a", "b"]},
+				"tags": {"type": "array", "minItems": 1, "items": {"const": "x"}}
+			},
+			"additionalProperties": false
+		}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		args string
+		want []FieldError
+	}{
+		{`["abc", 4]`, nil},
+		{`["abc", 4, {"id": "a", "tags": ["x"]}]`, nil},
+		{`["", 4]`, []FieldError{{Param: 0, Rule: "minLength"}, {Param: 0, Rule: "pattern"}}},
+		{`["abcdef", 4]`, []FieldError{{Param: 0, Rule: "maxLength"}}},
+		{`[1, 4]`, []FieldError{{Param: 0, Rule: "type"}}},
+		{`["abc", 2.5]`, []FieldError{{Param: 1, Rule: "type"}}},
+		{`["abc", 0]`, []FieldError{{Param: 1, Rule: "minimum"}}},
+		{`["abc", 10]`, []FieldError{{Param: 1, Rule: "exclusiveMaximum"}}},
+		{`["abc", 3]`, []FieldError{{Param: 1, Rule: "multipleOf"}}},
+		{`["abc", 4, {}]`, []FieldError{{Param: 2, Path: "id", Rule: "required"}}},
+		{`["abc", 4, {"id": "c", "x": 1}]`, []FieldError{{Param: 2, Path: "id", Rule: "enum"}, {Param: 2, Path: "x", Rule: "additionalProperties"}}},
+		{`["abc", 4, {"id": "a", "tags": []}]`, []FieldError{{Param: 2, Path: "tags", Rule: "minItems"}}},
+		{`["abc", 4, {"id": "a", "tags": ["y"]}]`, []FieldError{{Param: 2, Path: "tags[0]", Rule: "const"}}},
+		{`["abc", 4, {"id": "a"}, {"id": "b"}]`, []FieldError{{Param: -1, Rule: "maxItems"}}},
+	} {
+		var params []json.RawMessage
+		if err := json.Unmarshal([]byte(tt.args), &params); err != nil {
+			t.Fatal(err)
+		}
+		var got []FieldError
+		var verr *ValidationError
+		if err := validateParams(JSONCodec, s, params); errors.As(err, &verr) {
+			for _, f := range verr.Fields {
+				got = append(got, FieldError{Param: f.Param, Path: f.Path, Rule: f.Rule})
+			}
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: errors %+v, want %+v", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestInvalidParamsSchema(t *testing.T) {
+	for _, schema := range []string{`[]`, `{"pattern": "("}`, `{`} {
+		if _, err := parseParamsSchema(json.RawMessage(schema)); err == nil {
+			t.Errorf("%s: no error", schema)
+		}
+	}
+}
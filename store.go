@@ -0,0 +1,384 @@
+package webview2
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"strconv"
+	"sync"
+)
+
+// Methods called by window.go.store to write to a store and to check that
+// its snapshot is current. They are answered unless functions are bound
+// under the same names.
+const (
+	storeWriteMethod = "__storeWrite"
+	storeSyncMethod  = "__storeSync"
+)
+
+// StoreConflict selects how a Store handles writes from JavaScript that
+// were based on a version other than the current one.
+type StoreConflict int
+
+const (
+	// StoreConflictReject rejects the write with a GoError with code
+	// "conflict" and status 409. The page already received the newer
+	// version and may retry. This is the default.
+	StoreConflictReject StoreConflict = iota
+
+	// StoreConflictMerge applies the patch of the write to the current
+	// value. The write is rejected as with StoreConflictReject only if the
+	// patch does not apply, e.g. because it changes a member that was
+	// removed.
+	StoreConflictMerge
+)
+
+// StoreOptions configures a store created with NewStoreWithOptions.
+type StoreOptions struct {
+	// ReadOnly rejects writes from JavaScript with a GoError with code
+	// "forbidden".
+	ReadOnly bool
+
+	// Conflict selects how outdated writes from JavaScript are handled.
+	Conflict StoreConflict
+}
+
+// Store is a value shared between Go and the documents of a WebView. Go
+// changes it with Set and Update, and every change is sent to JavaScript
+// as a JSON Patch. Documents fetch the current snapshot when they load.
+//
+// In JavaScript, window.go.store(name) returns the store. Its value and
+// version properties hold the current state, subscribe(cb) calls cb with
+// the value and version now and after every change and returns a function
+// that unsubscribes, and set(value) and update(fn) write back to Go. Writes
+// resolve to the new version and are subject to StoreOptions.
+//
+// T must be encodable as JSON; the value is exchanged as JSON whatever the
+// codec of the WebView.
+type Store[T any] struct {
+	s *store
+
+	// value, subs and nextSub are guarded by s.m.
+	value   T
+	subs    map[int]func(T)
+	nextSub int
+}
+
+// store is the part of a Store that does not depend on its type.
+type store struct {
+	w    *webview
+	name string
+	opts StoreOptions
+
+	m       sync.Mutex
+	version uint64
+	doc     interface{}
+	closed  bool
+
+	// scriptID identifies the init script that hydrates new documents.
+	scriptID string
+
+	// decode sets the typed value from doc. It is called with m held.
+	decode func(doc interface{}) error
+	// notify calls the Go subscribers with the typed value.
+	notify func()
+}
+
+// NewStore creates a store named name with the initial value v. It is
+// NewStoreWithOptions with the default options.
+func NewStore[T any](w WebView, name string, v T) (*Store[T], error) {
+	return NewStoreWithOptions(w, name, v, StoreOptions{})
+}
+
+// NewStoreWithOptions creates a store named name with the initial value v.
+// w must have been created by this package, e.g. with NewWithOptions or
+// NewFake. Names must be unique per WebView until the store is closed.
+func NewStoreWithOptions[T any](w WebView, name string, v T, opts StoreOptions) (*Store[T], error) {
+	c, ok := w.(interface{ core() *webview })
+	if !ok {
+		return nil, errors.New("stores require a WebView created by webview2")
+	}
+	doc, err := toJSONDoc(v)
+	if err != nil {
+		return nil, err
+	}
+	st := &Store[T]{value: v, subs: map[int]func(T){}}
+	s := &store{w: c.core(), name: name, opts: opts, version: 1, doc: doc}
+	s.decode = func(doc interface{}) error {
+		var v T
+		if err := fromJSONDoc(doc, &v); err != nil {
+			return err
+		}
+		st.value = v
+		return nil
+	}
+	s.notify = func() {
+		s.m.Lock()
+		v := st.value
+		subs := make([]func(T), 0, len(st.subs))
+		for _, f := range st.subs {
+			subs = append(subs, f)
+		}
+		s.m.Unlock()
+		for _, f := range subs {
+			f(v)
+		}
+	}
+	st.s = s
+
+	s.w.m.Lock()
+	if _, exists := s.w.stores[name]; exists {
+		s.w.m.Unlock()
+		return nil, errors.New("store " + strconv.Quote(name) + " already exists")
+	}
+	s.w.stores[name] = s
+	s.w.m.Unlock()
+	s.addScript()
+	return st, nil
+}
+
+// Name returns the name of the store.
+func (st *Store[T]) Name() string { return st.s.name }
+
+// Get returns the current value. It must not be modified; use Update.
+func (st *Store[T]) Get() T {
+	st.s.m.Lock()
+	defer st.s.m.Unlock()
+	return st.value
+}
+
+// Version returns the current version. It starts at 1 and increases with
+// every change, whether made by Go or JavaScript.
+func (st *Store[T]) Version() uint64 {
+	st.s.m.Lock()
+	defer st.s.m.Unlock()
+	return st.s.version
+}
+
+// Set replaces the value with v and sends the difference to JavaScript.
+func (st *Store[T]) Set(v T) error {
+	doc, err := toJSONDoc(v)
+	if err != nil {
+		return err
+	}
+	return st.s.commit(func(interface{}) (interface{}, error) {
+		st.value = v
+		return doc, nil
+	})
+}
+
+// Update calls f with a copy of the current value and sets the value to
+// the result. f is called with the store locked, so that no other change
+// comes in between; it must not call methods of the store.
+func (st *Store[T]) Update(f func(v *T)) error {
+	return st.s.commit(func(old interface{}) (interface{}, error) {
+		var v T
+		if err := fromJSONDoc(old, &v); err != nil {
+			return nil, err
+		}
+		f(&v)
+		doc, err := toJSONDoc(v)
+		if err != nil {
+			return nil, err
+		}
+		st.value = v
+		return doc, nil
+	})
+}
+
+// Subscribe calls f with the value after every change, including writes
+// from JavaScript, on the goroutine that made the change. It returns a
+// function that unsubscribes f.
+func (st *Store[T]) Subscribe(f func(v T)) func() {
+	st.s.m.Lock()
+	id := st.nextSub
+	st.nextSub++
+	st.subs[id] = f
+	st.s.m.Unlock()
+	return func() {
+		st.s.m.Lock()
+		delete(st.subs, id)
+		st.s.m.Unlock()
+	}
+}
+
+// Close removes the store. New documents no longer receive it, and
+// documents that did keep their last snapshot. If the ID of its init script
+// is not known yet, the script is removed once it is.
+func (st *Store[T]) Close() {
+	s := st.s
+	s.w.m.Lock()
+	if s.w.stores[s.name] == s {
+		delete(s.w.stores, s.name)
+	}
+	s.w.m.Unlock()
+	s.m.Lock()
+	s.closed = true
+	id := s.scriptID
+	s.scriptID = ""
+	s.m.Unlock()
+	if id != "" {
+		s.w.Dispatch(func() {
+			if err := s.w.browser.RemoveInitScript(id); err != nil {
+				log.Printf("failed to remove script of store %s: %v", s.name, err)
+			}
+		})
+	}
+}
+
+// commit changes the value to what change returns and sends the patch to
+// JavaScript. change is called with m held and also sets the typed value.
+func (s *store) commit(change func(old interface{}) (interface{}, error)) error {
+	s.m.Lock()
+	if s.closed {
+		s.m.Unlock()
+		return errors.New("store " + strconv.Quote(s.name) + " is closed")
+	}
+	doc, err := change(s.doc)
+	if err != nil {
+		s.m.Unlock()
+		return err
+	}
+	s.publish(doc)
+	s.m.Unlock()
+	s.notify()
+	return nil
+}
+
+// publish makes doc the new version and sends the difference from the
+// previous one to all documents. It is called with m held, so that patches
+// are sent in order.
+func (s *store) publish(doc interface{}) {
+	ops := diffJSON(nil, "", s.doc, doc)
+	s.doc = doc
+	if len(ops) == 0 {
+		return
+	}
+	s.version++
+	m, err := s.w.message("storePatch", s.name, s.version, ops)
+	if err != nil {
+		log.Printf("failed to send patch of store %s: %v", s.name, err)
+		return
+	}
+	s.w.Dispatch(func() {
+		s.w.deliver(m)
+	})
+}
+
+// addScript adds the init script that makes new documents fetch the
+// snapshot, and has the current document fetch it too. The script does not
+// hold the snapshot, so that changes do not need to replace it.
+func (s *store) addScript() {
+	script := "window._rpc.storeInit(" + jsString(s.name) + ")"
+	s.w.Dispatch(func() {
+		s.w.browser.AddInitScript(script, func(id string, err error) {
+			if err != nil {
+				log.Printf("failed to hydrate store %s: %v", s.name, err)
+				return
+			}
+			s.m.Lock()
+			closed := s.closed
+			if !closed {
+				s.scriptID = id
+			}
+			s.m.Unlock()
+			if closed {
+				_ = s.w.browser.RemoveInitScript(id)
+			}
+		})
+		s.w.browser.Eval("if (window._rpc) " + script)
+	})
+}
+
+// write applies a patch written by JavaScript to version base, and returns
+// the new version.
+func (s *store) write(base uint64, ops []patchOp) (uint64, error) {
+	if s.opts.ReadOnly {
+		return 0, &Error{Code: "forbidden", Status: 403, Message: "store " + strconv.Quote(s.name) + " is read-only"}
+	}
+	conflict := &Error{Code: "conflict", Status: 409, Message: "store " + strconv.Quote(s.name) + " changed since version " + strconv.FormatUint(base, 10)}
+	s.m.Lock()
+	if s.closed {
+		s.m.Unlock()
+		return 0, errUnknownBinding(storeWriteMethod)
+	}
+	if base != s.version && s.opts.Conflict == StoreConflictReject {
+		s.m.Unlock()
+		return 0, conflict
+	}
+	doc, err := applyPatch(s.doc, ops)
+	if err == nil {
+		err = s.decode(doc)
+	}
+	if err != nil {
+		s.m.Unlock()
+		if base != s.version {
+			return 0, conflict
+		}
+		return 0, &Error{Code: "invalid_argument", Status: 400, Message: "invalid patch of store " + strconv.Quote(s.name), Err: err}
+	}
+	s.publish(doc)
+	version := s.version
+	s.m.Unlock()
+	s.notify()
+	return version, nil
+}
+
+// storeCall answers the calls of storeWriteMethod and storeSyncMethod.
+func (w *webview) storeCall(bc *BindingCall) (interface{}, error) {
+	var name string
+	var version uint64
+	var ops []patchOp
+	targets := []interface{}{&name, &version}
+	if bc.Method == storeWriteMethod {
+		targets = append(targets, &ops)
+	}
+	if len(bc.Params) != len(targets) {
+		return nil, &paramsError{errors.New("function expects " + strconv.Itoa(len(targets)) + " arguments")}
+	}
+	for i, t := range targets {
+		if err := bc.Codec.Unmarshal(bc.Params[i], t); err != nil {
+			return nil, &paramsError{err}
+		}
+	}
+	w.m.Lock()
+	s := w.stores[name]
+	w.m.Unlock()
+	if s == nil {
+		return nil, &Error{Code: "not_found", Status: 404, Message: "no store is named " + name}
+	}
+	if bc.Method == storeWriteMethod {
+		return s.write(version, ops)
+	}
+	// Sync: documents send the version they have, 0 before they were
+	// hydrated, and get the current snapshot if it is outdated.
+	s.m.Lock()
+	defer s.m.Unlock()
+	if version == s.version {
+		return nil, nil
+	}
+	return []interface{}{s.version, s.doc}, nil
+}
+
+func (w *webview) core() *webview { return w }
+
+func (f *Fake) core() *webview { return f.w }
+
+// toJSONDoc returns v as a generic JSON value.
+func toJSONDoc(v interface{}) (interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var doc interface{}
+	err = json.Unmarshal(b, &doc)
+	return doc, err
+}
+
+func fromJSONDoc(doc interface{}, v interface{}) error {
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
@@ -0,0 +1,178 @@
+package webview2
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+)
+
+type counter struct {
+	Count int      `json:"count"`
+	Items []string `json:"items"`
+}
+
+func TestStore(t *testing.T) {
+	for _, codec := range []Codec{JSONCodec, MessagePackCodec, CBORCodec} {
+		t.Run(codec.Name(), func(t *testing.T) {
+			ctx := context.Background()
+			f := NewFake(WebViewOptions{Codec: codec})
+			st, err := NewStore(f, "counter", counter{Items: []string{}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var seen []int
+			st.Subscribe(func(v counter) { seen = append(seen, v.Count) })
+
+			if err := st.Update(func(v *counter) { v.Count++ }); err != nil {
+				t.Fatal(err)
+			}
+			if st.Version() != 2 {
+				t.Errorf("version %d after Update, want 2", st.Version())
+			}
+
+			// JavaScript writes a patch based on the current version.
+			res, err := f.Call(ctx, storeWriteMethod, "counter", 2, []patchOp{
+				{Op: "replace", Path: "/count", Value: 5},
+				{Op: "add", Path: "/items/-", Value: "a"},
+			})
+			if err != nil || string(res) != "3" {
+				t.Fatalf("write = %s, %v, want version 3", res, err)
+			}
+			if v := st.Get(); v.Count != 5 || len(v.Items) != 1 || v.Items[0] != "a" {
+				t.Errorf("value %+v after write", v)
+			}
+			if want := []int{1, 5}; len(seen) != 2 || seen[0] != want[0] || seen[1] != want[1] {
+				t.Errorf("subscriber saw %v, want %v", seen, want)
+			}
+
+			// Writes based on an old version conflict.
+			_, err = f.Call(ctx, storeWriteMethod, "counter", 2, []patchOp{{Op: "replace", Path: "/count", Value: 6}})
+			var e *Error
+			if !errors.As(err, &e) || e.Code != "conflict" || e.Status != 409 {
+				t.Errorf("outdated write = %v, want a conflict", err)
+			}
+
+			_, err = f.Call(ctx, storeWriteMethod, "counter", 3, []patchOp{{Op: "remove", Path: "/missing"}})
+			if !errors.As(err, &e) || e.Code != "invalid_argument" {
+				t.Errorf("invalid patch = %v, want invalid_argument", err)
+			}
+
+			// Documents with an old snapshot get the current one.
+			res, err = f.Call(ctx, storeSyncMethod, "counter", 1)
+			if err != nil || string(res) != `[3,{"count":5,"items":["a"]}]` {
+				t.Errorf("sync = %s, %v", res, err)
+			}
+			res, err = f.Call(ctx, storeSyncMethod, "counter", 3)
+			if err != nil || string(res) != "null" {
+				t.Errorf("sync of the current version = %s, %v", res, err)
+			}
+
+			st.Close()
+			if err := st.Set(counter{}); err == nil {
+				t.Error("Set of a closed store succeeded")
+			}
+			_, err = f.Call(ctx, storeSyncMethod, "counter", 1)
+			if !errors.As(err, &e) || e.Code != "not_found" {
+				t.Errorf("sync of a closed store = %v, want not_found", err)
+			}
+		})
+	}
+}
+
+func TestStoreOptions(t *testing.T) {
+	ctx := context.Background()
+	f := NewFake(WebViewOptions{})
+	ro, err := NewStoreWithOptions(f, "ro", counter{}, StoreOptions{ReadOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = f.Call(ctx, storeWriteMethod, "ro", 1, []patchOp{{Op: "replace", Path: "/count", Value: 1}})
+	var e *Error
+	if !errors.As(err, &e) || e.Code != "forbidden" || ro.Get().Count != 0 {
+		t.Errorf("write to a read-only store = %v, want forbidden", err)
+	}
+
+	merge, err := NewStoreWithOptions(f, "merge", counter{}, StoreOptions{Conflict: StoreConflictMerge})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := merge.Set(counter{Count: 1}); err != nil {
+		t.Fatal(err)
+	}
+	res, err := f.Call(ctx, storeWriteMethod, "merge", 1, []patchOp{{Op: "add", Path: "/items", Value: []string{"b"}}})
+	if err != nil || string(res) != "3" {
+		t.Errorf("merged write = %s, %v, want version 3", res, err)
+	}
+	if v := merge.Get(); v.Count != 1 || len(v.Items) != 1 {
+		t.Errorf("value %+v after merged write", v)
+	}
+
+	if _, err := NewStore[int](nil, "x", 1); err == nil {
+		t.Error("store without a webview created")
+	}
+}
+
+// initScriptBrowser records the init scripts added and removed. If
+// deferred is set, the IDs of added scripts are reported when the test calls the
+// functions in pending.
+type initScriptBrowser struct {
+	browser
+	added    *[]string
+	removed  *[]string
+	deferred bool
+	pending  *[]func()
+}
+
+func (b initScriptBrowser) AddInitScript(script string, done func(id string, err error)) {
+	*b.added = append(*b.added, script)
+	id := strconv.Itoa(len(*b.added))
+	if b.deferred {
+		*b.pending = append(*b.pending, func() { done(id, nil) })
+		return
+	}
+	done(id, nil)
+}
+
+func (b initScriptBrowser) RemoveInitScript(id string) error {
+	*b.removed = append(*b.removed, id)
+	return nil
+}
+
+func TestStoreScript(t *testing.T) {
+	var added, removed []string
+	f := NewFake(WebViewOptions{})
+	f.w.browser = initScriptBrowser{browser: f.w.browser, added: &added, removed: &removed}
+	st, err := NewStore(f, "counter", counter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		if err := st.Update(func(v *counter) { v.Count++ }); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(added) != 1 || added[0] != `window._rpc.storeInit("counter")` || len(removed) != 0 {
+		t.Errorf("init scripts added %q, removed %q, want one added", added, removed)
+	}
+	st.Close()
+	if len(removed) != 1 || removed[0] != "1" {
+		t.Errorf("Close removed init scripts %q, want 1", removed)
+	}
+
+	// The script is removed once its ID is known.
+	var pending []func()
+	added, removed = nil, nil
+	f.w.browser = initScriptBrowser{browser: f.w.browser.(initScriptBrowser).browser, added: &added, removed: &removed, deferred: true, pending: &pending}
+	st, err = NewStore(f, "counter", counter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	st.Close()
+	for _, done := range pending {
+		done()
+	}
+	if len(added) != 1 || len(removed) != 1 || removed[0] != "1" {
+		t.Errorf("init scripts added %q, removed %q after an early Close", added, removed)
+	}
+}
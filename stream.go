@@ -0,0 +1,111 @@
+package webview2
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"github.com/logicossoftware/go-webview2/internal/bindtype"
+)
+
+func streamValue(res interface{}) (reflect.Value, bool) {
+	if res == nil {
+		return reflect.Value{}, false
+	}
+	v := reflect.ValueOf(res)
+	return v, bindtype.IsStream(v.Type())
+}
+
+// addCredit allows the stream of call id to send n more items.
+func (w *webview) addCredit(id int, n int) {
+	w.m.Lock()
+	call, ok := w.pending[id]
+	if ok {
+		call.credit += n
+	}
+	w.m.Unlock()
+	if ok {
+		select {
+		case call.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// takeCredit blocks until JavaScript has room for another item. It returns
+// false if the call was cancelled while waiting.
+func (w *webview) takeCredit(ctx context.Context, call *pendingCall) bool {
+	for {
+		w.m.Lock()
+		if call.credit > 0 {
+			call.credit--
+			w.m.Unlock()
+			return true
+		}
+		w.m.Unlock()
+		select {
+		case <-call.wake:
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// stream forwards the items of a channel or iter.Seq to the async iterator
+// returned to JavaScript. The stream ends when the source is exhausted, or
+// early when the call's context is cancelled, e.g. because JavaScript left
+// its for await loop.
+func (w *webview) stream(ctx context.Context, info CallInfo, callID int, call *pendingCall, v reflect.Value) {
+	defer w.finishCall(callID, call)
+
+	var failed error
+	push := func(item reflect.Value) bool {
+		if !w.takeCredit(ctx, call) {
+			return false
+		}
+		start := time.Now()
+		m, err := w.callMessage(call, "item", callID, item.Interface())
+		call.stats.encoded(time.Since(start))
+		if err != nil {
+			failed = err
+			return false
+		}
+		w.recorder.recordValue(TraceEntry{Kind: TraceItem, ID: callID}, item.Interface(), nil)
+		w.send(call, m)
+		return true
+	}
+
+	if v.Kind() == reflect.Chan {
+		if !v.IsNil() {
+			cases := []reflect.SelectCase{
+				{Dir: reflect.SelectRecv, Chan: v},
+				{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+			}
+			for {
+				chosen, item, ok := reflect.Select(cases)
+				if chosen != 0 || !ok || !push(item) {
+					break
+				}
+			}
+		}
+	} else if !v.IsNil() {
+		yieldType := v.Type().In(0)
+		yield := reflect.MakeFunc(yieldType, func(args []reflect.Value) []reflect.Value {
+			more := ctx.Err() == nil && push(args[0])
+			return []reflect.Value{reflect.ValueOf(more).Convert(yieldType.Out(0))}
+		})
+		func() {
+			defer w.recoverPanic(info, &failed)
+			v.Call([]reflect.Value{yield})
+		}()
+	}
+
+	if failed != nil {
+		w.respond(call, callID, nil, failed)
+	} else if ctx.Err() == nil {
+		w.respond(call, callID, nil, nil)
+	} else {
+		// JavaScript left the loop, or the document went away.
+		call.stats.finish(nil)
+	}
+}
@@ -0,0 +1,36 @@
+package webview2
+
+import (
+	"errors"
+	"io"
+	"sort"
+
+	"github.com/logicossoftware/go-webview2/pkg/tsgen"
+)
+
+// WriteTypeScript writes TypeScript declarations for the functions currently
+// bound to w. See package tsgen for how Go types are mapped, and the
+// webview2-tsgen command for generating the same declarations from source.
+// w must have been created by this package, e.g. with NewWithOptions or
+// NewFake.
+func WriteTypeScript(out io.Writer, w WebView) error {
+	c, ok := w.(interface{ core() *webview })
+	if !ok {
+		return errors.New("webview is not a go-webview2 instance")
+	}
+	wv := c.core()
+	g := &tsgen.Generator{}
+	wv.m.Lock()
+	defer wv.m.Unlock()
+	names := make([]string, 0, len(wv.bindings))
+	for name := range wv.bindings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := g.Add(name, wv.bindings[name].f); err != nil {
+			return err
+		}
+	}
+	return g.Write(out)
+}
@@ -0,0 +1,20 @@
+package webview2
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteTypeScript(t *testing.T) {
+	f := NewFake(WebViewOptions{})
+	if err := f.Bind("app.read", func(name string) (Bytes, error) { return nil, nil }); err != nil {
+		t.Fatal(err)
+	}
+	var sb strings.Builder
+	if err := WriteTypeScript(&sb, f); err != nil {
+		t.Fatal(err)
+	}
+	if want := "read: Go.Bound<(arg0: string, signal?: AbortSignal) => Promise<Uint8Array | null>>;"; !strings.Contains(sb.String(), want) {
+		t.Errorf("declarations do not contain\n%s\ngot:\n%s", want, sb.String())
+	}
+}
@@ -0,0 +1,237 @@
+package webview2
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/logicossoftware/go-webview2/internal/bindtype"
+)
+
+// ValidationError is the error a call of a bound function fails with when
+// its arguments do not satisfy the validate tags of their struct fields or
+// the schema of the binding. It is a CodedError with code
+// "invalid_argument" and status 400, and the data of the GoError is the
+// list of fields.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// FieldError is a value that failed validation.
+type FieldError struct {
+	// Param is the index of the argument, not counting a context.Context
+	// parameter. It is -1 for the arguments as a whole, e.g. if there are
+	// fewer than the minItems of BindOptions.ParamsSchema.
+	Param int `json:"param"`
+
+	// Path locates the value within the argument, using the names of the
+	// JSON encoding, e.g. "items[2].name". It is empty for the argument
+	// itself.
+	Path string `json:"path,omitempty"`
+
+	// Rule is the validate rule or JSON Schema keyword that failed.
+	Rule string `json:"rule"`
+
+	Message string `json:"message"`
+}
+
+func (e FieldError) Error() string {
+	name := "arguments"
+	if e.Param >= 0 {
+		name = "argument " + strconv.Itoa(e.Param)
+	}
+	if e.Path != "" {
+		name += " " + e.Path
+	}
+	return name + " " + e.Message
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Error()
+	}
+	return "invalid arguments: " + strings.Join(msgs, "; ")
+}
+
+func (e *ValidationError) ErrorCode() string      { return "invalid_argument" }
+func (e *ValidationError) ErrorStatus() int       { return 400 }
+func (e *ValidationError) ErrorData() interface{} { return e.Fields }
+
+// validator collects the errors of one argument.
+type validator struct {
+	param  int
+	errors []FieldError
+}
+
+func (v *validator) fail(path, rule, format string, args ...interface{}) {
+	v.errors = append(v.errors, FieldError{Param: v.param, Path: path, Rule: rule, Message: fmt.Sprintf(format, args...)})
+}
+
+// validateValue checks the validate tags of the struct fields in value.
+//
+// A tag lists rules separated by commas: "required" rejects zero values,
+// empty strings and empty collections; "omitempty" skips the remaining
+// rules for such values; "min=n", "max=n" and "len=n" bound the length of
+// strings in characters, the length of collections, or numbers; and
+// "oneof=a b c" lists the allowed values.
+func (v *validator) validateValue(rv reflect.Value, path string) {
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return
+		}
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Struct:
+		t := rv.Type()
+		for _, f := range cachedFields(t) {
+			fv, ok := fieldByIndex(rv, f.index)
+			if !ok {
+				continue
+			}
+			fpath := joinPath(path, f.name)
+			if tag := t.FieldByIndex(f.index).Tag.Get("validate"); tag != "" {
+				v.applyRules(fv, fpath, tag)
+			}
+			v.validateValue(fv, fpath)
+		}
+	case reflect.Slice, reflect.Array:
+		if bindtype.IsBinary(rv.Type()) {
+			return
+		}
+		for i := 0; i < rv.Len(); i++ {
+			v.validateValue(rv.Index(i), path+"["+strconv.Itoa(i)+"]")
+		}
+	case reflect.Map:
+		iter := rv.MapRange()
+		for iter.Next() {
+			k, _ := mapKey(iter.Key())
+			v.validateValue(iter.Value(), path+"["+strconv.Quote(k)+"]")
+		}
+	}
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func (v *validator) applyRules(fv reflect.Value, path, tag string) {
+	for _, rule := range strings.Split(tag, ",") {
+		name, arg, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			if isEmptyValue(fv) {
+				v.fail(path, name, "is required")
+				return
+			}
+		case "omitempty":
+			if isEmptyValue(fv) {
+				return
+			}
+		case "min", "max", "len":
+			n, _ := strconv.ParseFloat(arg, 64)
+			size, unit, ok := measure(fv)
+			if !ok {
+				continue
+			}
+			switch {
+			case name == "min" && size < n:
+				v.fail(path, name, "must be at least %s%s", arg, unit)
+			case name == "max" && size > n:
+				v.fail(path, name, "must be at most %s%s", arg, unit)
+			case name == "len" && size != n:
+				v.fail(path, name, "must be exactly %s%s", arg, unit)
+			}
+		case "oneof":
+			for fv.Kind() == reflect.Pointer && !fv.IsNil() {
+				fv = fv.Elem()
+			}
+			s := fmt.Sprint(fv.Interface())
+			found := false
+			for _, allowed := range strings.Fields(arg) {
+				if s == allowed {
+					found = true
+					break
+				}
+			}
+			if !found {
+				v.fail(path, name, "must be one of %s", strings.Join(strings.Fields(arg), ", "))
+			}
+		}
+	}
+}
+
+// measure returns what min, max and len compare for v, and the unit to
+// report it in.
+func measure(v reflect.Value) (float64, string, bool) {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return 0, "", false
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), " characters long", true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(v.Len()), " items long", true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), "", true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint()), "", true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), "", true
+	}
+	return 0, "", false
+}
+
+// checkValidateTags reports malformed validate tags in the struct types
+// reachable from t, so they are found when a function is bound rather than
+// when it is called.
+func checkValidateTags(t reflect.Type) error {
+	return checkTags(t, map[reflect.Type]bool{})
+}
+
+func checkTags(t reflect.Type, seen map[reflect.Type]bool) error {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || seen[t] {
+		return nil
+	}
+	seen[t] = true
+	for _, f := range cachedFields(t) {
+		sf := t.FieldByIndex(f.index)
+		if tag := sf.Tag.Get("validate"); tag != "" {
+			for _, rule := range strings.Split(tag, ",") {
+				name, arg, _ := strings.Cut(rule, "=")
+				var err error
+				switch name {
+				case "required", "omitempty":
+				case "min", "max", "len":
+					_, err = strconv.ParseFloat(arg, 64)
+				case "oneof":
+					if strings.TrimSpace(arg) == "" {
+						err = errors.New("no values")
+					}
+				default:
+					err = errors.New("unknown rule")
+				}
+				if err != nil {
+					return fmt.Errorf("invalid validate rule %q of %s.%s: %v", rule, t, sf.Name, err)
+				}
+			}
+		}
+		if err := checkTags(sf.Type, seen); err != nil {
+			return err
+		}
+	}
+	return nil
+}
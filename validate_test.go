@@ -0,0 +1,71 @@
+package webview2
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestValidateTags(t *testing.T) {
+	type item struct {
+		Name string `json:"name" validate:"required"`
+	}
+	type request struct {
+		Site  string   `json:"site" validate:"required,max=5"`
+		Kind  string   `json:"kind" validate:"oneof=a b"`
+		Note  string   `json:"note" validate:"omitempty,min=2"`
+		Code  string   `validate:"len=3"`
+		Count int      `json:"count" validate:"min=1,max=9"`
+		Items []item   `json:"items" validate:"max=2"`
+		Tags  []string `json:"-"`
+	}
+	f := NewFake(WebViewOptions{})
+	if err := f.Bind("save", func(r request) bool { return true }); err != nil {
+		t.Fatal(err)
+	}
+	valid := request{Site: "s", Kind: "a", Code: "abc", Count: 1, Items: []item{{Name: "x"}}}
+	if _, err := f.Call(context.Background(), "save", valid); err != nil {
+		t.Fatalf("valid request: %v", err)
+	}
+	invalid := request{Site: "toolong", Kind: "c", Note: "x", Code: "ab", Count: 10, Items: []item{{}, {Name: "x"}, {Name: "y"}}}
+	_, err := f.Call(context.Background(), "save", invalid)
+	var e *Error
+	if !errors.As(err, &e) || e.Code != "invalid_argument" {
+		t.Fatalf("invalid request: %v, want invalid_argument", err)
+	}
+	want := map[string]string{
+		"site":          "max",
+		"kind":          "oneof",
+		"note":          "min",
+		"Code":          "len",
+		"count":         "max",
+		"items":         "max",
+		"items[0].name": "required",
+	}
+	fields, _ := e.Data.([]interface{})
+	got := map[string]string{}
+	for _, f := range fields {
+		m, _ := f.(map[string]interface{})
+		path, _ := m["path"].(string)
+		rule, _ := m["rule"].(string)
+		got[path] = rule
+	}
+	if len(got) != len(want) {
+		t.Errorf("errors %v, want %v", got, want)
+	}
+	for path, rule := range want {
+		if got[path] != rule {
+			t.Errorf("%s: rule %q, want %q", path, got[path], rule)
+		}
+	}
+}
+
+func TestInvalidValidateTags(t *testing.T) {
+	f := NewFake(WebViewOptions{})
+	type request struct {
+		Name string `validate:"unknown"`
+	}
+	if err := f.Bind("save", func(r request) {}); err == nil {
+		t.Error("Bind accepted an unknown validate rule")
+	}
+}
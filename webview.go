@@ -1,61 +1,145 @@
-//go:build windows
-// +build windows
-
 package webview2
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"log"
 	"reflect"
 	"strconv"
+	"strings"
 	"sync"
+	"time"
 	"unsafe"
 
-	"github.com/logicossoftware/go-webview2/internal/w32"
-	"github.com/logicossoftware/go-webview2/pkg/edge"
-
-	"golang.org/x/sys/windows"
+	"github.com/logicossoftware/go-webview2/internal/bindtype"
+	"github.com/logicossoftware/go-webview2/pkg/tsgen"
 )
 
-var (
-	windowContext     = map[uintptr]interface{}{}
-	windowContextSync sync.RWMutex
-)
-
-func getWindowContext(wnd uintptr) interface{} {
-	windowContextSync.RLock()
-	defer windowContextSync.RUnlock()
-	return windowContext[wnd]
-}
-
-func setWindowContext(wnd uintptr, data interface{}) {
-	windowContextSync.Lock()
-	defer windowContextSync.Unlock()
-	windowContext[wnd] = data
-}
-
 type browser interface {
 	Embed(hwnd uintptr) bool
 	Resize()
 	Navigate(url string)
 	NavigateToString(htmlContent string)
 	Init(script string)
+	AddInitScript(script string, done func(id string, err error))
+	RemoveInitScript(id string) error
 	Eval(script string)
+	PostMessage(message string)
+	PostMessageJSON(json string) error
+	EvalWithResult(script string, done func(result string, err error))
 	NotifyParentWindowPositionChanged() error
 	Focus()
 }
 
 type webview struct {
-	hwnd       uintptr
-	mainthread uintptr
+	nativeWindow
+
 	browser    browser
 	autofocus  bool
-	maxsz      w32.Point
-	minsz      w32.Point
 	m          sync.Mutex
-	bindings   map[string]interface{}
+	bindings   map[string]*binding
 	dispatchq  []func()
+	executor   executor
+	pending    map[int]*pendingCall
+	generation uint64
+
+	// navigationID identifies the navigation of the current document.
+	navigationID uint64
+
+	blobs         map[string]*blob
+	blobThreshold int
+
+	// documentOrigin is the origin of the current document, which alone
+	// may fetch blobs.
+	documentOrigin string
+
+	callbacks    map[int]*JSResult
+	nextCallback int
+
+	handlers   []*eventHandler
+	middleware []BindingMiddleware
+
+	allowedOrigins []string
+	callRejected   func(info CallInfo)
+	bindingPanic   func(err *PanicError)
+
+	jsonrpc bool
+
+	// internalCallID numbers the calls not made by the JavaScript runtime,
+	// see nextInternalID.
+	internalCallID int
+
+	codec Codec
+
+	// chunkThreshold and chunkSize configure the chunks of large messages.
+	// deliveries counts the messages delivered to the current document.
+	chunkThreshold int
+	chunkSize      int
+	deliveries     uint64
+
+	stores map[string]*store
+
+	metrics     *metrics
+	spanStarter func(ctx context.Context, info CallInfo) (context.Context, func(err error))
+
+	recorder *recorder
+
+	// callFinished, if set, is called when a call is no longer pending. A
+	// Replayer waits for the calls of a trace with it.
+	callFinished func()
+
+	// headless is set for webviews without a window, such as those of a
+	// Fake or a Replayer. Dispatch runs functions immediately, and Run
+	// blocks until Terminate is called.
+	headless  bool
+	done      chan struct{}
+	terminate sync.Once
+}
+
+// binding is a function registered with Bind or BindObject.
+type binding struct {
+	f       interface{}
+	path    []string
+	origins []string
+	timeout time.Duration
+	schema  *paramsSchema
+
+	// scriptID identifies the script that installs the JavaScript stub. It
+	// is empty until WebView2 reported it. Guarded by webview.m.
+	scriptID string
+	removed  bool
+}
+
+// BindOptions configures a function bound with BindWithOptions.
+type BindOptions struct {
+	// AllowedOrigins lists the origins of documents that may call the
+	// function, in the form of WebViewOptions.AllowedOrigins. If empty,
+	// WebViewOptions.AllowedOrigins applies.
+	AllowedOrigins []string
+
+	// Timeout bounds the duration of calls of the function. When it passes,
+	// the context of the call is cancelled and its promise rejected with a
+	// GoError with code "timeout", even if the function has not returned.
+	// The result the function returns later is discarded. With
+	// BindingExecutionSync, however, the function blocks the UI thread, so
+	// the rejection is only delivered once it returned; only timeouts set
+	// by JavaScript pre-empt it. JavaScript can set shorter timeouts with
+	// withTimeout or withOptions.
+	Timeout time.Duration
+
+	// ParamsSchema is a JSON Schema the array of arguments must satisfy
+	// before the function is called; prefixItems or items describe the
+	// individual arguments, and other keywords such as minItems apply to the
+	// array as a whole. Struct fields are also checked against their
+	// validate tags, with or without a schema. Calls with invalid arguments
+	// are rejected with a ValidationError. The supported keywords are type,
+	// enum, const, minLength, maxLength, pattern, minimum, maximum,
+	// exclusiveMinimum, exclusiveMaximum, multipleOf, properties, required,
+	// additionalProperties, items, prefixItems, minItems and maxItems.
+	ParamsSchema json.RawMessage
 }
 
 type WindowOptions struct {
@@ -85,375 +169,204 @@ type WebViewOptions struct {
 	// DownloadStartingCallback is invoked when WebView2 starts a download.
 	// The args object lets you cancel the download, mark it handled (to hide
 	// the default download UI), and change the result file path.
-	DownloadStartingCallback func(sender *edge.ICoreWebView2, args *edge.ICoreWebView2DownloadStartingEventArgs)
-}
-
-// New creates a new webview in a new window.
-func New(debug bool) WebView { return NewWithOptions(WebViewOptions{Debug: debug}) }
-
-// NewWindow creates a new webview using an existing window.
-//
-// Deprecated: Use NewWithOptions.
-func NewWindow(debug bool, window unsafe.Pointer) WebView {
-	return NewWithOptions(WebViewOptions{Debug: debug, Window: window})
+	DownloadStartingCallback DownloadStartingHandler
+
+	// BindingExecution selects how functions registered with Bind are
+	// executed. By default they run on the UI thread, which keeps the window
+	// from responding until they return. Results are always delivered to
+	// JavaScript on the UI thread.
+	BindingExecution BindingExecution
+
+	// BindingWorkers is the number of goroutines used by
+	// BindingExecutionPool. It defaults to runtime.NumCPU().
+	BindingWorkers int
+
+	// BlobThreshold is the size in bytes above which binary results of
+	// bound functions are fetched by JavaScript through a virtual host
+	// instead of being embedded base64-encoded in a script. It defaults to
+	// 64 KiB. A negative value always embeds them. Only the current
+	// document may fetch them, and those it does not fetch within 30
+	// seconds or whose call is aborted are dropped.
+	BlobThreshold int
+
+	// ChunkThreshold is the size in bytes above which messages to
+	// JavaScript, such as results, stream items and events, are posted in
+	// chunks with PostWebMessageAsJSON rather than evaluated as a script,
+	// which is slow for large values. The JavaScript runtime checks and
+	// reassembles the chunks, and rejects the call a message belongs to if
+	// they are corrupt or could not be posted. It defaults to 256 KiB. A negative value disables
+	// chunking.
+	ChunkThreshold int
+
+	// ChunkSize is the maximum size in bytes of the data of a chunk. It
+	// defaults to 64 KiB, or to ChunkThreshold if that is smaller.
+	ChunkSize int
+
+	// AllowedOrigins lists the origins of documents that may call bound
+	// functions and emit events, e.g. "https://app.example". An entry like
+	// "https://*.example.com" allows all subdomains, and "null" allows
+	// documents with an opaque origin such as data URIs. If empty, all
+	// documents are allowed. BindOptions can set other origins per binding.
+	AllowedOrigins []string
+
+	// JSONRPC makes bound functions callable with JSON-RPC 2.0 requests
+	// posted with window.chrome.webview.postMessage, for use by existing
+	// JSON-RPC clients. Responses are posted back as strings and received
+	// as message events of window.chrome.webview. Named parameters are
+	// passed to functions taking a single parameter, e.g. a struct.
+	// Streaming results are not supported. The functions installed on
+	// window keep working either way.
+	JSONRPC bool
+
+	// CallRejectedCallback is invoked on the UI thread when a document calls
+	// a bound function its origin is not allowed to call. The promise of the
+	// call is rejected with a GoError with code "forbidden".
+	CallRejectedCallback func(info CallInfo)
+
+	// OnBindingPanic is invoked when a bound function, a middleware or an
+	// event handler panics, on the goroutine that ran it, after the panic
+	// was recovered. The promise of the call is rejected with a
+	// window.go.GoPanic. For event handlers, the Method of the PanicError is
+	// "event " followed by the topic. If nil, the panic and its stack are
+	// logged.
+	OnBindingPanic func(err *PanicError)
+
+	// Recorder receives a trace of the traffic between Go and JavaScript
+	// as JSON lines of TraceEntry: the messages JavaScript posts, the
+	// responses and stream items of calls, emitted events and evaluated
+	// scripts. A Replayer can run the trace against bound functions without
+	// a browser. Writes are serialized.
+	Recorder io.Writer
+
+	// Codec encodes the messages of the JavaScript runtime. It defaults to
+	// JSONCodec. With MessagePackCodec or CBORCodec, messages are posted
+	// base64-encoded, binary data is not base64-encoded again, and
+	// BlobThreshold does not apply. The Name of the codec must be one of
+	// those implemented by the JavaScript runtime, "json", "msgpack" or
+	// "cbor"; "json" is always handled by encoding/json. JSON-RPC requests
+	// and the results of EvalResult are JSON regardless.
+	Codec Codec
+
+	// LatencyBuckets are the upper bounds of the buckets of the histograms
+	// returned by Metrics. The defaults range from 50µs to 10s.
+	LatencyBuckets []time.Duration
+
+	// StartSpan is invoked when a document calls a bound function, before
+	// middleware, to start a span for the call, e.g. with OpenTelemetry. It
+	// returns the context for the call, which must be derived from ctx, and
+	// a function invoked with the outcome of the call when it was answered
+	// or, for streaming results, when the stream ended. info.TraceID holds
+	// the trace ID JavaScript sent, if any. The context reaches middleware
+	// through BindingCall.Context and functions that take a
+	// context.Context.
+	StartSpan func(ctx context.Context, info CallInfo) (context.Context, func(err error))
 }
 
-// NewWithOptions creates a new webview using the provided options.
-func NewWithOptions(options WebViewOptions) WebView {
-	w := &webview{}
-	w.bindings = map[string]interface{}{}
-	w.autofocus = options.AutoFocus
-
-	chromium := edge.NewChromium()
-	chromium.MessageCallback = w.msgcb
-	chromium.DataPath = options.DataPath
-	chromium.SetPermission(edge.CoreWebView2PermissionKindClipboardRead, edge.CoreWebView2PermissionStateAllow)
-	chromium.DownloadStartingCallback = options.DownloadStartingCallback
-
-	w.browser = chromium
-	w.mainthread, _, _ = w32.Kernel32GetCurrentThreadID.Call()
-	if !w.CreateWithOptions(options.WindowOptions) {
-		return nil
-	}
-
-	settings, err := chromium.GetSettings()
-	if err != nil {
-		log.Fatal(err)
-	}
-	// disable context menu
-	err = settings.PutAreDefaultContextMenusEnabled(options.Debug)
-	if err != nil {
-		log.Fatal(err)
-	}
-	// disable developer tools
-	err = settings.PutAreDevToolsEnabled(options.Debug)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	return w
-}
-
-type rpcMessage struct {
-	ID     int               `json:"id"`
-	Method string            `json:"method"`
-	Params []json.RawMessage `json:"params"`
-}
-
-func jsString(v interface{}) string { b, _ := json.Marshal(v); return string(b) }
-
-func (w *webview) msgcb(msg string) {
-	d := rpcMessage{}
-	if err := json.Unmarshal([]byte(msg), &d); err != nil {
-		log.Printf("invalid RPC message: %v", err)
-		return
-	}
-
-	id := strconv.Itoa(d.ID)
-	if res, err := w.callbinding(d); err != nil {
-		w.Dispatch(func() {
-			w.Eval("window._rpc[" + id + "].reject(" + jsString(err.Error()) + "); window._rpc[" + id + "] = undefined")
-		})
-	} else if b, err := json.Marshal(res); err != nil {
-		w.Dispatch(func() {
-			w.Eval("window._rpc[" + id + "].reject(" + jsString(err.Error()) + "); window._rpc[" + id + "] = undefined")
-		})
-	} else {
-		w.Dispatch(func() {
-			w.Eval("window._rpc[" + id + "].resolve(" + string(b) + "); window._rpc[" + id + "] = undefined")
-		})
+// newWebview returns a webview configured by options, without a browser.
+func newWebview(options WebViewOptions) (*webview, error) {
+	codec := options.Codec
+	if codec == nil {
+		codec = JSONCodec
 	}
-}
-
-func (w *webview) callbinding(d rpcMessage) (interface{}, error) {
-	w.m.Lock()
-	f, ok := w.bindings[d.Method]
-	w.m.Unlock()
-	if !ok {
-		return nil, nil
+	switch codec.Name() {
+	case "json", "msgpack", "cbor":
+	default:
+		return nil, fmt.Errorf("unsupported codec %q", codec.Name())
 	}
-
-	v := reflect.ValueOf(f)
-	isVariadic := v.Type().IsVariadic()
-	numIn := v.Type().NumIn()
-	if (isVariadic && len(d.Params) < numIn-1) || (!isVariadic && len(d.Params) != numIn) {
-		return nil, errors.New("function arguments mismatch")
+	w := &webview{codec: codec}
+	w.done = make(chan struct{})
+	w.bindings = map[string]*binding{}
+	w.pending = map[int]*pendingCall{}
+	w.blobs = map[string]*blob{}
+	w.callbacks = map[int]*JSResult{}
+	w.stores = map[string]*store{}
+	w.blobThreshold = options.BlobThreshold
+	if w.blobThreshold == 0 {
+		w.blobThreshold = defaultBlobThreshold
 	}
-	args := []reflect.Value{}
-	for i := range d.Params {
-		var arg reflect.Value
-		if isVariadic && i >= numIn-1 {
-			arg = reflect.New(v.Type().In(numIn - 1).Elem())
-		} else {
-			arg = reflect.New(v.Type().In(i))
-		}
-		if err := json.Unmarshal(d.Params[i], arg.Interface()); err != nil {
-			return nil, err
-		}
-		args = append(args, arg.Elem())
+	w.chunkThreshold = options.ChunkThreshold
+	if w.chunkThreshold == 0 {
+		w.chunkThreshold = defaultChunkThreshold
 	}
-
-	errorType := reflect.TypeOf((*error)(nil)).Elem()
-	res := v.Call(args)
-	switch len(res) {
-	case 0:
-		// No results from the function, just return nil
-		return nil, nil
-
-	case 1:
-		// One result may be a value, or an error
-		if res[0].Type().Implements(errorType) {
-			if res[0].Interface() != nil {
-				return nil, res[0].Interface().(error)
-			}
-			return nil, nil
-		}
-		return res[0].Interface(), nil
-
-	case 2:
-		// Two results: first one is value, second is error
-		if !res[1].Type().Implements(errorType) {
-			return nil, errors.New("second return value must be an error")
-		}
-		if res[1].Interface() == nil {
-			return res[0].Interface(), nil
+	w.chunkSize = options.ChunkSize
+	if w.chunkSize <= 0 {
+		w.chunkSize = defaultChunkSize
+		if w.chunkThreshold > 0 && w.chunkThreshold < w.chunkSize {
+			w.chunkSize = w.chunkThreshold
 		}
-		return res[0].Interface(), res[1].Interface().(error)
-
-	default:
-		return nil, errors.New("unexpected number of return values")
 	}
+	w.autofocus = options.AutoFocus
+	w.allowedOrigins = options.AllowedOrigins
+	w.callRejected = options.CallRejectedCallback
+	w.bindingPanic = options.OnBindingPanic
+	w.jsonrpc = options.JSONRPC
+	w.metrics = newMetrics(options.LatencyBuckets)
+	w.spanStarter = options.StartSpan
+	w.recorder = newRecorder(options.Recorder)
+	w.executor = newExecutor(options.BindingExecution, options.BindingWorkers)
+	return w, nil
 }
 
-func wndproc(hwnd, msg, wp, lp uintptr) uintptr {
-	if w, ok := getWindowContext(hwnd).(*webview); ok {
-		switch msg {
-		case w32.WMMove, w32.WMMoving:
-			_ = w.browser.NotifyParentWindowPositionChanged()
-		case w32.WMNCLButtonDown:
-			_, _, _ = w32.User32SetFocus.Call(w.hwnd)
-			r, _, _ := w32.User32DefWindowProcW.Call(hwnd, msg, wp, lp)
-			return r
-		case w32.WMSize:
-			w.browser.Resize()
-		case w32.WMActivate:
-			if wp == w32.WAInactive {
-				break
-			}
-			if w.autofocus {
-				w.browser.Focus()
-			}
-		case w32.WMClose:
-			_, _, _ = w32.User32DestroyWindow.Call(hwnd)
-		case w32.WMDestroy:
-			w.Terminate()
-		case w32.WMGetMinMaxInfo:
-			lpmmi := (*w32.MinMaxInfo)(unsafe.Pointer(lp))
-			if w.maxsz.X > 0 && w.maxsz.Y > 0 {
-				lpmmi.PtMaxSize = w.maxsz
-				lpmmi.PtMaxTrackSize = w.maxsz
-			}
-			if w.minsz.X > 0 && w.minsz.Y > 0 {
-				lpmmi.PtMinTrackSize = w.minsz
-			}
-		default:
-			r, _, _ := w32.User32DefWindowProcW.Call(hwnd, msg, wp, lp)
-			return r
-		}
-		return 0
+// runtimeScript returns the JavaScript side of the bridge for the codec.
+func (w *webview) runtimeScript() string {
+	if w.usesJSON() {
+		return rpcScript
 	}
-	r, _, _ := w32.User32DefWindowProcW.Call(hwnd, msg, wp, lp)
-	return r
+	return "window._rpcConfig = {codec: " + jsString(w.codec.Name()) + "};\n" + codecScript + "\n" + rpcScript
 }
 
-func (w *webview) Create(debug bool, window unsafe.Pointer) bool {
-	// This function signature stopped making sense a long time ago.
-	// It is but legacy cruft at this point.
-	return w.CreateWithOptions(WindowOptions{})
-}
-
-func (w *webview) CreateWithOptions(opts WindowOptions) bool {
-	var hinstance windows.Handle
-	_ = windows.GetModuleHandleEx(0, nil, &hinstance)
-
-	var icon uintptr
-	if opts.IconId == 0 {
-		// load default icon
-		icow, _, _ := w32.User32GetSystemMetrics.Call(w32.SystemMetricsCxIcon)
-		icoh, _, _ := w32.User32GetSystemMetrics.Call(w32.SystemMetricsCyIcon)
-		icon, _, _ = w32.User32LoadImageW.Call(uintptr(hinstance), 32512, icow, icoh, 0)
-	} else {
-		// load icon from resource
-		icon, _, _ = w32.User32LoadImageW.Call(uintptr(hinstance), uintptr(opts.IconId), 1, 0, 0, w32.LR_DEFAULTSIZE|w32.LR_SHARED)
-	}
-
-	className, _ := windows.UTF16PtrFromString("webview")
-	wc := w32.WndClassExW{
-		CbSize:        uint32(unsafe.Sizeof(w32.WndClassExW{})),
-		HInstance:     hinstance,
-		LpszClassName: className,
-		HIcon:         windows.Handle(icon),
-		HIconSm:       windows.Handle(icon),
-		LpfnWndProc:   windows.NewCallback(wndproc),
-	}
-	_, _, _ = w32.User32RegisterClassExW.Call(uintptr(unsafe.Pointer(&wc)))
-
-	windowName, _ := windows.UTF16PtrFromString(opts.Title)
-
-	windowWidth := opts.Width
-	if windowWidth == 0 {
-		windowWidth = 640
-	}
-	windowHeight := opts.Height
-	if windowHeight == 0 {
-		windowHeight = 480
-	}
-
-	var posX, posY uint
-	if opts.Center {
-		// get screen size
-		screenWidth, _, _ := w32.User32GetSystemMetrics.Call(w32.SM_CXSCREEN)
-		screenHeight, _, _ := w32.User32GetSystemMetrics.Call(w32.SM_CYSCREEN)
-		// calculate window position
-		posX = (uint(screenWidth) - windowWidth) / 2
-		posY = (uint(screenHeight) - windowHeight) / 2
-	} else {
-		// use default position
-		posX = w32.CW_USEDEFAULT
-		posY = w32.CW_USEDEFAULT
-	}
-
-	w.hwnd, _, _ = w32.User32CreateWindowExW.Call(
-		0,
-		uintptr(unsafe.Pointer(className)),
-		uintptr(unsafe.Pointer(windowName)),
-		0xCF0000, // WS_OVERLAPPEDWINDOW
-		uintptr(posX),
-		uintptr(posY),
-		uintptr(windowWidth),
-		uintptr(windowHeight),
-		0,
-		0,
-		uintptr(hinstance),
-		0,
-	)
-	setWindowContext(w.hwnd, w)
-
-	_, _, _ = w32.User32ShowWindow.Call(w.hwnd, w32.SWShow)
-	_, _, _ = w32.User32UpdateWindow.Call(w.hwnd)
-	_, _, _ = w32.User32SetFocus.Call(w.hwnd)
-
-	if !w.browser.Embed(w.hwnd) {
-		return false
-	}
-	w.browser.Resize()
-	return true
+func (w *webview) Navigate(url string) {
+	w.browser.Navigate(url)
 }
 
-func (w *webview) Destroy() {
-	_, _, _ = w32.User32PostMessageW.Call(w.hwnd, w32.WMClose, 0, 0)
+func (w *webview) SetHtml(html string) {
+	w.browser.NavigateToString(html)
 }
 
-func (w *webview) Run() {
-	var msg w32.Msg
-	for {
-		_, _, _ = w32.User32GetMessageW.Call(
-			uintptr(unsafe.Pointer(&msg)),
-			0,
-			0,
-			0,
-		)
-		if msg.Message == w32.WMApp {
-			w.m.Lock()
-			q := append([]func(){}, w.dispatchq...)
-			w.dispatchq = []func(){}
-			w.m.Unlock()
-			for _, v := range q {
-				v()
-			}
-		} else if msg.Message == w32.WMQuit {
-			return
-		}
-		r, _, _ := w32.User32GetAncestor.Call(uintptr(msg.Hwnd), w32.GARoot)
-		r, _, _ = w32.User32IsDialogMessage.Call(r, uintptr(unsafe.Pointer(&msg)))
-		if r != 0 {
-			continue
-		}
-		_, _, _ = w32.User32TranslateMessage.Call(uintptr(unsafe.Pointer(&msg)))
-		_, _, _ = w32.User32DispatchMessageW.Call(uintptr(unsafe.Pointer(&msg)))
-	}
+func (w *webview) Init(js string) {
+	w.browser.Init(js)
 }
 
-func (w *webview) Terminate() {
-	_, _, _ = w32.User32PostQuitMessage.Call(0)
+func (w *webview) Eval(js string) {
+	w.recorder.record(TraceEntry{Kind: TraceEval, Script: js})
+	w.browser.Eval(js)
 }
 
-func (w *webview) Window() unsafe.Pointer {
-	return unsafe.Pointer(w.hwnd)
+func (w *webview) Bind(name string, f interface{}) error {
+	return w.BindWithOptions(name, f, BindOptions{})
 }
 
-func (w *webview) Navigate(url string) {
-	w.browser.Navigate(url)
+func (w *webview) BindWithOptions(name string, f interface{}, opts BindOptions) error {
+	return w.bind(name, []string{name}, f, opts)
 }
 
-func (w *webview) SetHtml(html string) {
-	w.browser.NavigateToString(html)
+func (w *webview) BindObject(namespace string, v interface{}) error {
+	return w.BindObjectWithOptions(namespace, v, BindOptions{})
 }
 
-func (w *webview) SetTitle(title string) {
-	_title, err := windows.UTF16FromString(title)
-	if err != nil {
-		_title, _ = windows.UTF16FromString("")
+func (w *webview) BindObjectWithOptions(namespace string, v interface{}, opts BindOptions) error {
+	path := strings.Split(namespace, ".")
+	for _, p := range path {
+		if p == "" {
+			return errors.New("invalid namespace " + strconv.Quote(namespace))
+		}
 	}
-	_, _, _ = w32.User32SetWindowTextW.Call(w.hwnd, uintptr(unsafe.Pointer(&_title[0])))
-}
-
-func (w *webview) SetSize(width int, height int, hints Hint) {
-	index := w32.GWLStyle
-	style, _, _ := w32.User32GetWindowLongPtrW.Call(w.hwnd, uintptr(index))
-	if hints == HintFixed {
-		style &^= (w32.WSThickFrame | w32.WSMaximizeBox)
-	} else {
-		style |= (w32.WSThickFrame | w32.WSMaximizeBox)
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() || rv.NumMethod() == 0 {
+		return errors.New("object has no exported methods")
 	}
-	_, _, _ = w32.User32SetWindowLongPtrW.Call(w.hwnd, uintptr(index), style)
-
-	if hints == HintMax {
-		w.maxsz.X = int32(width)
-		w.maxsz.Y = int32(height)
-	} else if hints == HintMin {
-		w.minsz.X = int32(width)
-		w.minsz.Y = int32(height)
-	} else {
-		r := w32.Rect{}
-		r.Left = 0
-		r.Top = 0
-		r.Right = int32(width)
-		r.Bottom = int32(height)
-		_, _, _ = w32.User32AdjustWindowRect.Call(uintptr(unsafe.Pointer(&r)), w32.WSOverlappedWindow, 0)
-		_, _, _ = w32.User32SetWindowPos.Call(
-			w.hwnd, 0, uintptr(r.Left), uintptr(r.Top), uintptr(r.Right-r.Left), uintptr(r.Bottom-r.Top),
-			w32.SWPNoZOrder|w32.SWPNoActivate|w32.SWPNoMove|w32.SWPFrameChanged)
-		w.browser.Resize()
+	for i := 0; i < rv.NumMethod(); i++ {
+		name := tsgen.MethodName(rv.Type().Method(i).Name)
+		if err := w.bind(namespace+"."+name, append(path[:len(path):len(path)], name), rv.Method(i).Interface(), opts); err != nil {
+			return errors.New(rv.Type().Method(i).Name + ": " + err.Error())
+		}
 	}
+	return nil
 }
 
-func (w *webview) Init(js string) {
-	w.browser.Init(js)
-}
-
-func (w *webview) Eval(js string) {
-	w.browser.Eval(js)
-}
-
-func (w *webview) Dispatch(f func()) {
-	w.m.Lock()
-	w.dispatchq = append(w.dispatchq, f)
-	w.m.Unlock()
-	_, _, _ = w32.User32PostThreadMessageW.Call(w.mainthread, w32.WMApp, 0, 0)
-}
-
-func (w *webview) Bind(name string, f interface{}) error {
+// bind registers f under name and installs its stub at path below window.
+// A previous binding of the same name is replaced.
+func (w *webview) bind(name string, path []string, f interface{}, opts BindOptions) error {
 	v := reflect.ValueOf(f)
 	if v.Kind() != reflect.Func {
 		return errors.New("only functions can be bound")
@@ -461,44 +374,80 @@ func (w *webview) Bind(name string, f interface{}) error {
 	if n := v.Type().NumOut(); n > 2 {
 		return errors.New("function may only return a value or a value+error")
 	}
+	for i := 0; i < v.Type().NumIn(); i++ {
+		if err := checkValidateTags(v.Type().In(i)); err != nil {
+			return err
+		}
+	}
+	stream := v.Type().NumOut() > 0 && bindtype.IsStream(v.Type().Out(0))
+	b := &binding{f: f, path: path, origins: opts.AllowedOrigins, timeout: opts.Timeout}
+	if opts.ParamsSchema != nil {
+		schema, err := parseParamsSchema(opts.ParamsSchema)
+		if err != nil {
+			return err
+		}
+		b.schema = schema
+	}
 	w.m.Lock()
-	w.bindings[name] = f
+	old := w.bindings[name]
+	w.bindings[name] = b
 	w.m.Unlock()
+	if old != nil {
+		w.removeScript(old)
+	}
 
-	w.Init("(function() { var name = " + jsString(name) + ";" + `
-		var RPC = window._rpc = (window._rpc || {nextSeq: 1});
-		window[name] = function() {
-		  var seq = RPC.nextSeq++;
-		  var promise = new Promise(function(resolve, reject) {
-			RPC[seq] = {
-			  resolve: resolve,
-			  reject: reject,
-			};
-		  });
-		  window.external.invoke(JSON.stringify({
-			id: seq,
-			method: name,
-			params: Array.prototype.slice.call(arguments),
-		  }));
-		  return promise;
+	script := "window._rpc.bind(" + jsString(name) + ", " + strconv.FormatBool(stream) + ", " + jsString(path) + ")"
+	w.browser.AddInitScript(script, func(id string, err error) {
+		if err != nil {
+			log.Printf("failed to add binding %s: %v", name, err)
+			return
+		}
+		w.m.Lock()
+		b.scriptID = id
+		removed := b.removed
+		w.m.Unlock()
+		if removed {
+			_ = w.browser.RemoveInitScript(id)
 		}
-	})()`)
+	})
+	// The current document gets the function as well, unless it was loaded
+	// before the runtime was injected.
+	w.browser.Eval("if (window._rpc) " + script)
 
 	return nil
 }
 
-func (w *webview) SetVirtualHostNameToFolderMapping(hostName, folderPath string, accessKind HostResourceAccessKind) error {
-	chromium, ok := w.browser.(*edge.Chromium)
-	if !ok {
-		return errors.New("browser is not a Chromium instance")
+func (w *webview) Unbind(name string) error {
+	w.m.Lock()
+	var removed []*binding
+	for n, b := range w.bindings {
+		if n == name || strings.HasPrefix(n, name+".") {
+			delete(w.bindings, n)
+			removed = append(removed, b)
+		}
 	}
-	return chromium.SetVirtualHostNameToFolderMapping(hostName, folderPath, accessKind)
+	w.m.Unlock()
+	if len(removed) == 0 {
+		return errors.New("no binding named " + strconv.Quote(name))
+	}
+	for _, b := range removed {
+		w.removeScript(b)
+		w.browser.Eval("window._rpc.unbind(" + jsString(b.path) + ")")
+	}
+	return nil
 }
 
-func (w *webview) ClearVirtualHostNameToFolderMapping(hostName string) error {
-	chromium, ok := w.browser.(*edge.Chromium)
-	if !ok {
-		return errors.New("browser is not a Chromium instance")
+// removeScript removes the stub of b from future documents. If the ID of
+// its script is not known yet, the script is removed once it is.
+func (w *webview) removeScript(b *binding) {
+	w.m.Lock()
+	b.removed = true
+	id := b.scriptID
+	w.m.Unlock()
+	if id == "" {
+		return
+	}
+	if err := w.browser.RemoveInitScript(id); err != nil {
+		log.Printf("failed to remove binding script: %v", err)
 	}
-	return chromium.ClearVirtualHostNameToFolderMapping(hostName)
 }
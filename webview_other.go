@@ -0,0 +1,70 @@
+//go:build !windows
+// +build !windows
+
+package webview2
+
+import (
+	"errors"
+	"unsafe"
+)
+
+// nativeWindow is empty: outside Windows there is no WebView2, and only
+// headless webviews such as that of a Fake exist.
+type nativeWindow struct{}
+
+var errNoWebView2 = errors.New("WebView2 is only available on Windows")
+
+// HostResourceAccessKind specifies the kind of cross-origin resource access
+// allowed for a virtual host name mapping.
+type HostResourceAccessKind uint32
+
+const (
+	// HostResourceAccessKindDeny denies all cross-origin resource access.
+	HostResourceAccessKindDeny HostResourceAccessKind = iota
+	// HostResourceAccessKindAllow allows all cross-origin resource access.
+	HostResourceAccessKindAllow
+	// HostResourceAccessKindDenyCors denies CORS but allows other cross-origin resource access.
+	HostResourceAccessKindDenyCors
+)
+
+// DownloadStartingHandler is the type of
+// WebViewOptions.DownloadStartingCallback. It is never invoked outside
+// Windows.
+type DownloadStartingHandler = func(sender, args unsafe.Pointer)
+
+// New returns nil outside Windows. Use NewFake to test code built on
+// WebView.
+func New(debug bool) WebView { return nil }
+
+// NewWindow returns nil outside Windows.
+//
+// Deprecated: Use NewWithOptions.
+func NewWindow(debug bool, window unsafe.Pointer) WebView { return nil }
+
+// NewWithOptions returns nil outside Windows, as if the window could not be
+// created. Use NewFake to test code built on WebView.
+func NewWithOptions(options WebViewOptions) WebView { return nil }
+
+func (w *webview) Destroy() { w.destroyHeadless() }
+
+func (w *webview) Run() { <-w.done }
+
+func (w *webview) Terminate() { w.terminateHeadless() }
+
+func (w *webview) Window() unsafe.Pointer { return nil }
+
+func (w *webview) SetTitle(title string) {}
+
+func (w *webview) SetSize(width int, height int, hints Hint) {}
+
+func (w *webview) Dispatch(f func()) { f() }
+
+func (w *webview) onUIThread() bool { return false }
+
+func (w *webview) SetVirtualHostNameToFolderMapping(hostName, folderPath string, accessKind HostResourceAccessKind) error {
+	return errNoWebView2
+}
+
+func (w *webview) ClearVirtualHostNameToFolderMapping(hostName string) error {
+	return errNoWebView2
+}
@@ -0,0 +1,111 @@
+package webview2
+
+import "testing"
+
+func TestUnsupportedCodec(t *testing.T) {
+	if f := NewFake(WebViewOptions{Codec: unsupportedCodec{}}); f != nil {
+		t.Error("NewFake accepted an unsupported codec")
+	}
+}
+
+type unsupportedCodec struct{ Codec }
+
+func (unsupportedCodec) Name() string { return "xml" }
+
+// scriptBrowser records the scripts evaluated in the current document.
+type scriptBrowser struct {
+	browser
+	scripts *[]string
+}
+
+func (b scriptBrowser) Eval(script string) {
+	*b.scripts = append(*b.scripts, script)
+	b.browser.Eval(script)
+}
+
+func TestRebind(t *testing.T) {
+	f := NewFake(WebViewOptions{})
+	var scripts []string
+	f.w.browser = scriptBrowser{f.w.browser, &scripts}
+	if err := f.Bind("add", func(a, b int) int { return a + b }); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Unbind("add"); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Bind("add", func(a, b int) int { return a + b }); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`if (window._rpc) window._rpc.bind("add", false, ["add"])`,
+		`window._rpc.unbind(["add"])`,
+		`if (window._rpc) window._rpc.bind("add", false, ["add"])`,
+	}
+	if len(scripts) != len(want) {
+		t.Fatalf("evaluated %q, want %q", scripts, want)
+	}
+	for i := range want {
+		if scripts[i] != want[i] {
+			t.Errorf("script %d = %s, want %s", i, scripts[i], want[i])
+		}
+	}
+	if res, err := f.Call(t.Context(), "add", 1, 2); err != nil || string(res) != "3" {
+		t.Errorf("add(1, 2) = %s, %v after rebinding", res, err)
+	}
+}
+
+type files struct{ prefix string }
+
+func (f *files) Read(name string) string { return f.prefix + name }
+func (f files) HTTPGet() string          { return "get" }
+
+func TestBindObject(t *testing.T) {
+	f := NewFake(WebViewOptions{})
+	var scripts []string
+	f.w.browser = scriptBrowser{f.w.browser, &scripts}
+	if err := f.BindObject("app.files", &files{prefix: "/"}); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`if (window._rpc) window._rpc.bind("app.files.httpGet", false, ["app","files","httpGet"])`,
+		`if (window._rpc) window._rpc.bind("app.files.read", false, ["app","files","read"])`,
+	}
+	if len(scripts) != len(want) || scripts[0] != want[0] || scripts[1] != want[1] {
+		t.Errorf("evaluated %q, want %q", scripts, want)
+	}
+	if res, err := f.Call(t.Context(), "app.files.read", "a"); err != nil || string(res) != `"/a"` {
+		t.Errorf("app.files.read = %s, %v", res, err)
+	}
+	if res, err := f.Call(t.Context(), "app.files.httpGet"); err != nil || string(res) != `"get"` {
+		t.Errorf("app.files.httpGet = %s, %v", res, err)
+	}
+
+	// Methods with pointer receivers are only bound for pointers.
+	if err := f.BindObject("values", files{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Call(t.Context(), "values.read", "a"); err == nil {
+		t.Error("method with a pointer receiver bound for a value")
+	}
+
+	// Unbinding a namespace removes the methods below it.
+	scripts = nil
+	if err := f.Unbind("app"); err != nil {
+		t.Fatal(err)
+	}
+	if len(scripts) != 2 {
+		t.Errorf("Unbind evaluated %q, want the removal of both methods", scripts)
+	}
+	if _, err := f.Call(t.Context(), "app.files.read", "a"); err == nil {
+		t.Error("app.files.read can be called after Unbind")
+	}
+
+	for _, ns := range []string{"", "app..files", ".app"} {
+		if err := f.BindObject(ns, &files{}); err == nil {
+			t.Errorf("BindObject(%q) succeeded", ns)
+		}
+	}
+	if err := f.BindObject("x", struct{}{}); err == nil {
+		t.Error("BindObject of a value without methods succeeded")
+	}
+}
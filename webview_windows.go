@@ -0,0 +1,412 @@
+//go:build windows
+// +build windows
+
+package webview2
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"unsafe"
+
+	"github.com/logicossoftware/go-webview2/internal/w32"
+	"github.com/logicossoftware/go-webview2/pkg/edge"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	windowContext     = map[uintptr]interface{}{}
+	windowContextSync sync.RWMutex
+)
+
+func getWindowContext(wnd uintptr) interface{} {
+	windowContextSync.RLock()
+	defer windowContextSync.RUnlock()
+	return windowContext[wnd]
+}
+
+func setWindowContext(wnd uintptr, data interface{}) {
+	windowContextSync.Lock()
+	defer windowContextSync.Unlock()
+	windowContext[wnd] = data
+}
+
+// nativeWindow is the window hosting WebView2.
+type nativeWindow struct {
+	hwnd       uintptr
+	mainthread uintptr
+	maxsz      w32.Point
+	minsz      w32.Point
+}
+
+// HostResourceAccessKind specifies the kind of cross-origin resource access
+// allowed for a virtual host name mapping.
+type HostResourceAccessKind = edge.COREWEBVIEW2_HOST_RESOURCE_ACCESS_KIND
+
+const (
+	// HostResourceAccessKindDeny denies all cross-origin resource access.
+	HostResourceAccessKindDeny HostResourceAccessKind = edge.COREWEBVIEW2_HOST_RESOURCE_ACCESS_KIND_DENY
+	// HostResourceAccessKindAllow allows all cross-origin resource access.
+	HostResourceAccessKindAllow HostResourceAccessKind = edge.COREWEBVIEW2_HOST_RESOURCE_ACCESS_KIND_ALLOW
+	// HostResourceAccessKindDenyCors denies CORS but allows other cross-origin resource access.
+	HostResourceAccessKindDenyCors HostResourceAccessKind = edge.COREWEBVIEW2_HOST_RESOURCE_ACCESS_KIND_DENY_CORS
+)
+
+// DownloadStartingHandler is the type of
+// WebViewOptions.DownloadStartingCallback.
+type DownloadStartingHandler = func(sender *edge.ICoreWebView2, args *edge.ICoreWebView2DownloadStartingEventArgs)
+
+// New creates a new webview in a new window.
+func New(debug bool) WebView { return NewWithOptions(WebViewOptions{Debug: debug}) }
+
+// NewWindow creates a new webview using an existing window.
+//
+// Deprecated: Use NewWithOptions.
+func NewWindow(debug bool, window unsafe.Pointer) WebView {
+	return NewWithOptions(WebViewOptions{Debug: debug, Window: window})
+}
+
+// NewWithOptions creates a new webview using the provided options. It
+// returns nil if the options are invalid or the window cannot be created.
+func NewWithOptions(options WebViewOptions) WebView {
+	w, err := newWebview(options)
+	if err != nil {
+		log.Printf("failed to create webview: %v", err)
+		return nil
+	}
+
+	chromium := edge.NewChromium()
+	chromium.WebMessageCallback = w.msgcb
+	chromium.DataPath = options.DataPath
+	chromium.SetPermission(edge.CoreWebView2PermissionKindClipboardRead, edge.CoreWebView2PermissionStateAllow)
+	chromium.DownloadStartingCallback = options.DownloadStartingCallback
+	// Calls are cancelled when a new document replaces the one that made
+	// them, not when a navigation starts: navigations that turn into
+	// downloads, are cancelled or return 204 keep the document.
+	chromium.ContentLoadingCallback = func(sender *edge.ICoreWebView2, args *edge.ICoreWebView2ContentLoadingEventArgs) {
+		source, _ := sender.GetSource()
+		id, _ := args.GetNavigationID()
+		w.documentLoading(id, source)
+	}
+	chromium.WebResourceRequestedCallback = func(req *edge.ICoreWebView2WebResourceRequest, args *edge.ICoreWebView2WebResourceRequestedEventArgs) {
+		w.serveBlob(chromium, req, args)
+	}
+
+	w.browser = chromium
+	w.mainthread, _, _ = w32.Kernel32GetCurrentThreadID.Call()
+	if !w.CreateWithOptions(options.WindowOptions) {
+		return nil
+	}
+	w.Init(w.runtimeScript())
+	chromium.AddWebResourceRequestedFilter(blobURL+"*", edge.COREWEBVIEW2_WEB_RESOURCE_CONTEXT_ALL)
+
+	settings, err := chromium.GetSettings()
+	if err != nil {
+		log.Fatal(err)
+	}
+	// disable context menu
+	err = settings.PutAreDefaultContextMenusEnabled(options.Debug)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// disable developer tools
+	err = settings.PutAreDevToolsEnabled(options.Debug)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return w
+}
+
+func wndproc(hwnd, msg, wp, lp uintptr) uintptr {
+	if w, ok := getWindowContext(hwnd).(*webview); ok {
+		switch msg {
+		case w32.WMMove, w32.WMMoving:
+			_ = w.browser.NotifyParentWindowPositionChanged()
+		case w32.WMNCLButtonDown:
+			_, _, _ = w32.User32SetFocus.Call(w.hwnd)
+			r, _, _ := w32.User32DefWindowProcW.Call(hwnd, msg, wp, lp)
+			return r
+		case w32.WMSize:
+			w.browser.Resize()
+		case w32.WMActivate:
+			if wp == w32.WAInactive {
+				break
+			}
+			if w.autofocus {
+				w.browser.Focus()
+			}
+		case w32.WMClose:
+			_, _, _ = w32.User32DestroyWindow.Call(hwnd)
+		case w32.WMDestroy:
+			w.cancelPending()
+			w.Terminate()
+		case w32.WMGetMinMaxInfo:
+			lpmmi := (*w32.MinMaxInfo)(unsafe.Pointer(lp))
+			if w.maxsz.X > 0 && w.maxsz.Y > 0 {
+				lpmmi.PtMaxSize = w.maxsz
+				lpmmi.PtMaxTrackSize = w.maxsz
+			}
+			if w.minsz.X > 0 && w.minsz.Y > 0 {
+				lpmmi.PtMinTrackSize = w.minsz
+			}
+		default:
+			r, _, _ := w32.User32DefWindowProcW.Call(hwnd, msg, wp, lp)
+			return r
+		}
+		return 0
+	}
+	r, _, _ := w32.User32DefWindowProcW.Call(hwnd, msg, wp, lp)
+	return r
+}
+
+func (w *webview) Create(debug bool, window unsafe.Pointer) bool {
+	// This function signature stopped making sense a long time ago.
+	// It is but legacy cruft at this point.
+	return w.CreateWithOptions(WindowOptions{})
+}
+
+func (w *webview) CreateWithOptions(opts WindowOptions) bool {
+	var hinstance windows.Handle
+	_ = windows.GetModuleHandleEx(0, nil, &hinstance)
+
+	var icon uintptr
+	if opts.IconId == 0 {
+		// load default icon
+		icow, _, _ := w32.User32GetSystemMetrics.Call(w32.SystemMetricsCxIcon)
+		icoh, _, _ := w32.User32GetSystemMetrics.Call(w32.SystemMetricsCyIcon)
+		icon, _, _ = w32.User32LoadImageW.Call(uintptr(hinstance), 32512, icow, icoh, 0)
+	} else {
+		// load icon from resource
+		icon, _, _ = w32.User32LoadImageW.Call(uintptr(hinstance), uintptr(opts.IconId), 1, 0, 0, w32.LR_DEFAULTSIZE|w32.LR_SHARED)
+	}
+
+	className, _ := windows.UTF16PtrFromString("webview")
+	wc := w32.WndClassExW{
+		CbSize:        uint32(unsafe.Sizeof(w32.WndClassExW{})),
+		HInstance:     hinstance,
+		LpszClassName: className,
+		HIcon:         windows.Handle(icon),
+		HIconSm:       windows.Handle(icon),
+		LpfnWndProc:   windows.NewCallback(wndproc),
+	}
+	_, _, _ = w32.User32RegisterClassExW.Call(uintptr(unsafe.Pointer(&wc)))
+
+	windowName, _ := windows.UTF16PtrFromString(opts.Title)
+
+	windowWidth := opts.Width
+	if windowWidth == 0 {
+		windowWidth = 640
+	}
+	windowHeight := opts.Height
+	if windowHeight == 0 {
+		windowHeight = 480
+	}
+
+	var posX, posY uint
+	if opts.Center {
+		// get screen size
+		screenWidth, _, _ := w32.User32GetSystemMetrics.Call(w32.SM_CXSCREEN)
+		screenHeight, _, _ := w32.User32GetSystemMetrics.Call(w32.SM_CYSCREEN)
+		// calculate window position
+		posX = (uint(screenWidth) - windowWidth) / 2
+		posY = (uint(screenHeight) - windowHeight) / 2
+	} else {
+		// use default position
+		posX = w32.CW_USEDEFAULT
+		posY = w32.CW_USEDEFAULT
+	}
+
+	w.hwnd, _, _ = w32.User32CreateWindowExW.Call(
+		0,
+		uintptr(unsafe.Pointer(className)),
+		uintptr(unsafe.Pointer(windowName)),
+		0xCF0000, // WS_OVERLAPPEDWINDOW
+		uintptr(posX),
+		uintptr(posY),
+		uintptr(windowWidth),
+		uintptr(windowHeight),
+		0,
+		0,
+		uintptr(hinstance),
+		0,
+	)
+	setWindowContext(w.hwnd, w)
+
+	_, _, _ = w32.User32ShowWindow.Call(w.hwnd, w32.SWShow)
+	_, _, _ = w32.User32UpdateWindow.Call(w.hwnd)
+	_, _, _ = w32.User32SetFocus.Call(w.hwnd)
+
+	if !w.browser.Embed(w.hwnd) {
+		return false
+	}
+	w.browser.Resize()
+	return true
+}
+
+func (w *webview) Destroy() {
+	if w.headless {
+		w.destroyHeadless()
+		return
+	}
+	_, _, _ = w32.User32PostMessageW.Call(w.hwnd, w32.WMClose, 0, 0)
+}
+
+func (w *webview) Run() {
+	if w.headless {
+		<-w.done
+		return
+	}
+	var msg w32.Msg
+	for {
+		_, _, _ = w32.User32GetMessageW.Call(
+			uintptr(unsafe.Pointer(&msg)),
+			0,
+			0,
+			0,
+		)
+		if msg.Message == w32.WMApp {
+			w.m.Lock()
+			q := append([]func(){}, w.dispatchq...)
+			w.dispatchq = []func(){}
+			w.m.Unlock()
+			for _, v := range q {
+				v()
+			}
+		} else if msg.Message == w32.WMQuit {
+			return
+		}
+		r, _, _ := w32.User32GetAncestor.Call(uintptr(msg.Hwnd), w32.GARoot)
+		r, _, _ = w32.User32IsDialogMessage.Call(r, uintptr(unsafe.Pointer(&msg)))
+		if r != 0 {
+			continue
+		}
+		_, _, _ = w32.User32TranslateMessage.Call(uintptr(unsafe.Pointer(&msg)))
+		_, _, _ = w32.User32DispatchMessageW.Call(uintptr(unsafe.Pointer(&msg)))
+	}
+}
+
+func (w *webview) Terminate() {
+	if w.headless {
+		w.terminateHeadless()
+		return
+	}
+	_, _, _ = w32.User32PostQuitMessage.Call(0)
+}
+
+func (w *webview) Window() unsafe.Pointer {
+	return unsafe.Pointer(w.hwnd)
+}
+
+func (w *webview) SetTitle(title string) {
+	if w.headless {
+		return
+	}
+	_title, err := windows.UTF16FromString(title)
+	if err != nil {
+		_title, _ = windows.UTF16FromString("")
+	}
+	_, _, _ = w32.User32SetWindowTextW.Call(w.hwnd, uintptr(unsafe.Pointer(&_title[0])))
+}
+
+func (w *webview) SetSize(width int, height int, hints Hint) {
+	if w.headless {
+		return
+	}
+	index := w32.GWLStyle
+	style, _, _ := w32.User32GetWindowLongPtrW.Call(w.hwnd, uintptr(index))
+	if hints == HintFixed {
+		style &^= (w32.WSThickFrame | w32.WSMaximizeBox)
+	} else {
+		style |= (w32.WSThickFrame | w32.WSMaximizeBox)
+	}
+	_, _, _ = w32.User32SetWindowLongPtrW.Call(w.hwnd, uintptr(index), style)
+
+	if hints == HintMax {
+		w.maxsz.X = int32(width)
+		w.maxsz.Y = int32(height)
+	} else if hints == HintMin {
+		w.minsz.X = int32(width)
+		w.minsz.Y = int32(height)
+	} else {
+		r := w32.Rect{}
+		r.Left = 0
+		r.Top = 0
+		r.Right = int32(width)
+		r.Bottom = int32(height)
+		_, _, _ = w32.User32AdjustWindowRect.Call(uintptr(unsafe.Pointer(&r)), w32.WSOverlappedWindow, 0)
+		_, _, _ = w32.User32SetWindowPos.Call(
+			w.hwnd, 0, uintptr(r.Left), uintptr(r.Top), uintptr(r.Right-r.Left), uintptr(r.Bottom-r.Top),
+			w32.SWPNoZOrder|w32.SWPNoActivate|w32.SWPNoMove|w32.SWPFrameChanged)
+		w.browser.Resize()
+	}
+}
+
+func (w *webview) Dispatch(f func()) {
+	if w.headless {
+		f()
+		return
+	}
+	w.m.Lock()
+	w.dispatchq = append(w.dispatchq, f)
+	w.m.Unlock()
+	_, _, _ = w32.User32PostThreadMessageW.Call(w.mainthread, w32.WMApp, 0, 0)
+}
+
+func (w *webview) SetVirtualHostNameToFolderMapping(hostName, folderPath string, accessKind HostResourceAccessKind) error {
+	chromium, ok := w.browser.(*edge.Chromium)
+	if !ok {
+		return errors.New("browser is not a Chromium instance")
+	}
+	return chromium.SetVirtualHostNameToFolderMapping(hostName, folderPath, accessKind)
+}
+
+func (w *webview) ClearVirtualHostNameToFolderMapping(hostName string) error {
+	chromium, ok := w.browser.(*edge.Chromium)
+	if !ok {
+		return errors.New("browser is not a Chromium instance")
+	}
+	return chromium.ClearVirtualHostNameToFolderMapping(hostName)
+}
+
+func (w *webview) onUIThread() bool {
+	if w.headless {
+		return false
+	}
+	id, _, _ := w32.Kernel32GetCurrentThreadID.Call()
+	return id == w.mainthread
+}
+
+// serveBlob answers the request of JavaScript for a blob. Each blob can be
+// fetched once, and only by the document it was delivered to: other
+// origins, such as those of iframes, fail the CORS check of the response.
+func (w *webview) serveBlob(chromium *edge.Chromium, req *edge.ICoreWebView2WebResourceRequest, args *edge.ICoreWebView2WebResourceRequestedEventArgs) {
+	uri, err := req.GetUri()
+	if err != nil || !strings.HasPrefix(uri, blobURL) {
+		return
+	}
+	b, ok := w.takeBlob(strings.TrimPrefix(uri, blobURL))
+	w.m.Lock()
+	origin := w.documentOrigin
+	w.m.Unlock()
+
+	status, reason := 200, "OK"
+	if !ok {
+		status, reason = 404, "Not Found"
+	}
+	headers := "Content-Type: application/octet-stream\r\nCache-Control: no-store"
+	if origin != "" {
+		headers += "\r\nAccess-Control-Allow-Origin: " + origin
+	}
+	resp, err := chromium.Environment().CreateWebResourceResponse(b, status, reason, headers)
+	if err != nil {
+		log.Printf("failed to create blob response: %v", err)
+		return
+	}
+	defer resp.Release()
+	if err := args.PutResponse(resp); err != nil {
+		log.Printf("failed to send blob: %v", err)
+	}
+}